@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Command imageresizer runs the image utilities of the imageresizer package from the command line.
+//
+// Usage:
+//
+//	imageresizer duplicates [-distance n] [-algorithm dhash|phash] dir
+//
+// The duplicates command scans dir and its subdirectories for near-duplicate images, such as
+// the same photo uploaded at different sizes, and prints each group of them, one path per line,
+// with groups separated by a blank line. Only files with the extension of a raster image format
+// (see imageExtensions) are read, under imageresizer.UntrustedInputPolicy, since dir may hold
+// files from anywhere; those that cannot be read as images are skipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiagomelo/go-image-resizer/imageresizer"
+)
+
+const usage = `usage: imageresizer <command> [arguments]
+
+commands:
+  duplicates [-distance n] [-algorithm dhash|phash] dir
+        groups the near-duplicate images found in dir
+`
+
+// hashAlgorithms maps the names accepted by the -algorithm flag to perceptual hash algorithms.
+var hashAlgorithms = map[string]imageresizer.HashAlgorithm{
+	"dhash": imageresizer.HASH_DIFFERENCE,
+	"phash": imageresizer.HASH_DCT,
+}
+
+// imageExtensions are the extensions of the files the duplicates command reads, those of raster
+// image formats.
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".avif": true,
+	".heic": true,
+	".heif": true,
+	".tif":  true,
+	".tiff": true,
+	".bmp":  true,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "duplicates":
+		err = duplicates(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	// Terminate should be called when the program exits.
+	imageresizer.Terminate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// duplicates runs the duplicates command with the given arguments.
+func duplicates(args []string) error {
+	flags := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	distance := flags.Int("distance", 10, "maximum number of differing hash bits between near-duplicates")
+	algorithm := flags.String("algorithm", "dhash", "perceptual hash algorithm: dhash or phash")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("duplicates takes exactly one directory, got %d arguments", flags.NArg())
+	}
+	hashAlgorithm, ok := hashAlgorithms[*algorithm]
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	// The directory may hold files from anywhere, which must not make ImageMagick read other
+	// files or run external programs.
+	if err := imageresizer.SetSecurityPolicy(imageresizer.UntrustedInputPolicy); err != nil {
+		return err
+	}
+	ir := imageresizer.New(imageresizer.WithHashAlgorithm(hashAlgorithm))
+	defer ir.Destroy()
+	hasher := ir.(imageresizer.PerceptualHasher)
+	hashes := make(map[string]uint64)
+	err := filepath.WalkDir(flags.Arg(0), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		hash, err := hasher.PerceptualHash(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", path, err)
+			return nil
+		}
+		hashes[path] = hash
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for n, group := range imageresizer.GroupDuplicates(hashes, *distance) {
+		if n > 0 {
+			fmt.Println()
+		}
+		for _, path := range group {
+			fmt.Println(path)
+		}
+	}
+	return nil
+}
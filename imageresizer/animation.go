@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// resizeAnimation resizes every frame of a multi-frame image, such as an animated GIF or WebP,
+// and writes them all to a single output. Frames are coalesced first, so that each one is a
+// full picture that can be resized on its own, and re-optimized into layers before writing.
+// Frame delays and the loop count are kept, as they belong to the frames themselves.
+func (i *imageResizer) resizeAnimation(imageFilePath string, frames int) (string, error) {
+	resizedImageFilePath := i.resizedImageFilePath(imageFilePath)
+	if i.animateWebP && strings.EqualFold(i.mw.GetImageFormat(), "GIF") {
+		// The output format follows the file extension.
+		resizedImageFilePath = strings.TrimSuffix(resizedImageFilePath, filepath.Ext(resizedImageFilePath)) + ".webp"
+	}
+	format := i.outputFormatOf(resizedImageFilePath)
+	if err := i.mw.Coalesce(); err != nil {
+		return "", errors.Wrap(err, "coalescing frames")
+	}
+	for frame := 0; frame < frames; frame++ {
+		i.mw.SetIteratorIndex(frame)
+		if err := i.resizeFrame(format); err != nil {
+			return "", errors.Wrapf(err, "frame %d", frame)
+		}
+	}
+	// WebP animations are encoded from full frames, so only other formats are optimized.
+	if format != "WEBP" {
+		if err := i.mw.OptimizeLayers(); err != nil {
+			return "", errors.Wrap(err, "optimizing layers")
+		}
+	}
+	if err := i.mw.WriteImages(resizedImageFilePath, true); err != nil {
+		return "", errors.Wrapf(err, "writing image %s", resizedImageFilePath)
+	}
+	return resizedImageFilePath, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resizeAnimation(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		format         string
+		animateWebP    bool
+		mockClosure    func(m *mockMagickWand)
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			name:           "animated gif",
+			format:         "GIF",
+			expectedOutput: "/path/to/dir/someImage_resized.gif",
+		},
+		{
+			name:           "animated gif to webp",
+			format:         "GIF",
+			animateWebP:    true,
+			expectedOutput: "/path/to/dir/someImage_resized.webp",
+		},
+		{
+			name:        "animated webp is not optimized",
+			input:       "someImage.webp",
+			format:      "WEBP",
+			animateWebP: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errOptimizeLayers = errors.New("optimize layers error")
+			},
+			expectedOutput: "/path/to/dir/someImage_resized.webp",
+		},
+		{
+			name:   "error when optimizing layers",
+			format: "GIF",
+			mockClosure: func(m *mockMagickWand) {
+				m.errOptimizeLayers = errors.New("optimize layers error")
+			},
+			expectedError: errors.New("optimizing layers: optimize layers error"),
+		},
+		{
+			name:   "error when coalescing frames",
+			format: "GIF",
+			mockClosure: func(m *mockMagickWand) {
+				m.errCoalesce = errors.New("coalesce error")
+			},
+			expectedError: errors.New("coalescing frames: coalesce error"),
+		},
+		{
+			name:   "error when writing images",
+			format: "GIF",
+			mockClosure: func(m *mockMagickWand) {
+				m.errWriteImages = errors.New("write images error")
+			},
+			expectedError: errors.New("writing image /path/to/dir/someImage_resized.gif: write images error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{numberImages: 3, format: tc.format}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:          m,
+				outputDir:   "/path/to/dir",
+				animateWebP: tc.animateWebP,
+			}
+			input := tc.input
+			if input == "" {
+				input = "someImage.gif"
+			}
+			output, err := ir.resizeAnimation(input, 3)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+				require.Equal(t, tc.expectedOutput, m.writtenImages)
+			}
+		})
+	}
+}
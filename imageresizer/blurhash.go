@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// base83Characters are the digits of the base 83 encoding used by BlurHash.
+const base83Characters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurHash encodes the width x height RGBA pixels into a BlurHash string with the given number
+// of horizontal and vertical components, following the reference implementation at
+// https://github.com/woltapp/blurhash. The alpha channel is ignored.
+func blurHash(xComponents, yComponents, width, height int, rgba []byte) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", fmt.Errorf("blurhash components must be between 1 and 9, got %dx%d", xComponents, yComponents)
+	}
+	if width <= 0 || height <= 0 || len(rgba) != width*height*4 {
+		return "", fmt.Errorf("invalid %dx%d pixels", width, height)
+	}
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for y := 0; y < yComponents; y++ {
+		for x := 0; x < xComponents; x++ {
+			factors = append(factors, blurHashFactor(x, y, width, height, rgba))
+		}
+	}
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximumValue := 0.0
+		for _, factor := range ac {
+			for _, component := range factor {
+				actualMaximumValue = math.Max(actualMaximumValue, math.Abs(component))
+			}
+		}
+		quantisedMaximumValue := int(math.Max(0, math.Min(82, math.Floor(actualMaximumValue*166-0.5))))
+		maximumValue = float64(quantisedMaximumValue+1) / 166
+		hash.WriteString(encodeBase83(quantisedMaximumValue, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, factor := range ac {
+		value := 0
+		for _, component := range factor {
+			quantised := int(math.Max(0, math.Min(18, math.Floor(signPow(component/maximumValue, 0.5)*9+9.5))))
+			value = value*19 + quantised
+		}
+		hash.WriteString(encodeBase83(value, 2))
+	}
+	return hash.String(), nil
+}
+
+// blurHashFactor returns the weight of the cosine basis function of the given horizontal and
+// vertical frequencies in the linear RGB pixels.
+func blurHashFactor(xComponent, yComponent, width, height int, rgba []byte) [3]float64 {
+	var factor [3]float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			basis := math.Cos(math.Pi*float64(xComponent*x)/float64(width)) *
+				math.Cos(math.Pi*float64(yComponent*y)/float64(height))
+			pixel := rgba[(y*width+x)*4:]
+			factor[0] += basis * sRGBToLinear(pixel[0])
+			factor[1] += basis * sRGBToLinear(pixel[1])
+			factor[2] += basis * sRGBToLinear(pixel[2])
+		}
+	}
+	normalisation := 2.0
+	if xComponent == 0 && yComponent == 0 {
+		normalisation = 1
+	}
+	scale := normalisation / float64(width*height)
+	for n := range factor {
+		factor[n] *= scale
+	}
+	return factor
+}
+
+// encodeBase83 encodes value into the given number of base 83 digits.
+func encodeBase83(value, length int) string {
+	digits := make([]byte, length)
+	for n := length - 1; n >= 0; n-- {
+		digits[n] = base83Characters[value%83]
+		value /= 83
+	}
+	return string(digits)
+}
+
+// sRGBToLinear converts an sRGB channel value into linear light, between 0 and 1.
+func sRGBToLinear(value byte) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear light value, between 0 and 1, into an sRGB channel value.
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// signPow raises the absolute value of value to exp, keeping its sign.
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
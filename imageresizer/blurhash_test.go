@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// solidRGBA returns width x height pixels of the given color.
+func solidRGBA(width, height int, color [4]byte) []byte {
+	return rgbaOf(width, height, func(x, y int) [4]byte { return color })
+}
+
+// rgbaOf returns width x height pixels whose colors are given by color.
+func rgbaOf(width, height int, color func(x, y int) [4]byte) []byte {
+	pixels := make([]byte, 0, width*height*4)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color(x, y)
+			pixels = append(pixels, c[:]...)
+		}
+	}
+	return pixels
+}
+
+func Test_blurHash(t *testing.T) {
+	testCases := []struct {
+		name           string
+		xComponents    int
+		yComponents    int
+		width          int
+		height         int
+		rgba           []byte
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			name:           "solid color",
+			xComponents:    4,
+			yComponents:    3,
+			width:          32,
+			height:         24,
+			rgba:           solidRGBA(32, 24, [4]byte{255, 0, 0, 255}),
+			expectedOutput: "LDTI:j]9fQ]9|co1fQo1fQfQfQfQ",
+		},
+		{
+			name:        "gradient",
+			xComponents: 4,
+			yComponents: 3,
+			width:       16,
+			height:      12,
+			rgba: rgbaOf(16, 12, func(x, y int) [4]byte {
+				return [4]byte{byte(x * 16), byte(y * 20), 128, 255}
+			}),
+			expectedOutput: "LsGuUU2@wxozqlR-jte=g0fjfQfj",
+		},
+		{
+			name:           "single component",
+			xComponents:    1,
+			yComponents:    1,
+			width:          2,
+			height:         2,
+			rgba:           solidRGBA(2, 2, [4]byte{255, 0, 0, 255}),
+			expectedOutput: "00TI:j",
+		},
+		{
+			name:          "too many components",
+			xComponents:   10,
+			yComponents:   3,
+			width:         2,
+			height:        2,
+			rgba:          solidRGBA(2, 2, [4]byte{}),
+			expectedError: errors.New("blurhash components must be between 1 and 9, got 10x3"),
+		},
+		{
+			name:          "missing pixels",
+			xComponents:   4,
+			yComponents:   3,
+			width:         2,
+			height:        2,
+			rgba:          solidRGBA(2, 1, [4]byte{}),
+			expectedError: errors.New("invalid 2x2 pixels"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := blurHash(tc.xComponents, tc.yComponents, tc.width, tc.height, tc.rgba)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func Test_encodeBase83(t *testing.T) {
+	require.Equal(t, "TI:j", encodeBase83(0xFF0000, 4))
+	require.Equal(t, "~", encodeBase83(82, 1))
+	require.Equal(t, "00", encodeBase83(0, 2))
+}
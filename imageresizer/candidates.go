@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Candidate describes the resized image encoded in one of the candidate formats.
+type Candidate struct {
+	Format  string  `json:"format"`         // Format of the output, e.g. "WEBP".
+	Path    string  `json:"path"`           // Path the output is written to; it is only written when kept.
+	Bytes   int     `json:"bytes"`          // Size of the output, in bytes.
+	Quality int     `json:"quality"`        // Compression quality the output is encoded with.
+	SSIM    float64 `json:"ssim,omitempty"` // Structural similarity achieved; set only when targeting an SSIM.
+}
+
+// Manifest lists the outputs in every candidate format, telling which one is the smallest.
+type Manifest struct {
+	Smallest   string      `json:"smallest"`   // Path of the smallest output.
+	Candidates []Candidate `json:"candidates"` // Outputs in each candidate format.
+}
+
+// candidateFilePath returns the path of the output in the given format, which replaces the
+// extension of the resized image file path.
+func candidateFilePath(resizedImageFilePath, format string) string {
+	return strings.TrimSuffix(resizedImageFilePath, filepath.Ext(resizedImageFilePath)) + "." + strings.ToLower(format)
+}
+
+// resizeToSmallestFormat resizes the current image once, then encodes it to every candidate
+// format and writes the smallest output, or all of them along with a manifest. The encoder
+// settings of each format are applied only to its own copy of the resized image.
+func (i *imageResizer) resizeToSmallestFormat(resizedImageFilePath string) (Result, error) {
+	formats := make([]string, len(i.candidateFormats))
+	for n, format := range i.candidateFormats {
+		formats[n] = FormatOf("." + format)
+	}
+	if err := i.transformFrame(); err != nil {
+		return Result{}, err
+	}
+	placeholder, err := i.resizedPlaceholder()
+	if err != nil {
+		return Result{}, err
+	}
+	colors, err := i.resizedColors()
+	if err != nil {
+		return Result{}, err
+	}
+	var (
+		result   = Result{Placeholder: placeholder, Colors: colors}
+		blobs    [][]byte
+		smallest int
+	)
+	for n, format := range formats {
+		blob, candidate, err := i.encodeCandidate(format)
+		if err != nil {
+			return Result{}, errors.Wrapf(err, "encoding candidate %s", format)
+		}
+		candidate.Path = candidateFilePath(resizedImageFilePath, format)
+		result.Candidates = append(result.Candidates, candidate)
+		blobs = append(blobs, blob)
+		if candidate.Bytes < result.Candidates[smallest].Bytes {
+			smallest = n
+		}
+	}
+	best := result.Candidates[smallest]
+	result.Path, result.Quality, result.SSIM = best.Path, best.Quality, best.SSIM
+	if !i.keepAllCandidates {
+		return result, writeFile(best.Path, blobs[smallest])
+	}
+	for n, candidate := range result.Candidates {
+		if err := writeFile(candidate.Path, blobs[n]); err != nil {
+			return Result{}, err
+		}
+	}
+	manifest, err := json.MarshalIndent(Manifest{Smallest: best.Path, Candidates: result.Candidates}, "", "  ")
+	if err != nil {
+		return Result{}, errors.Wrap(err, "encoding manifest")
+	}
+	result.ManifestPath = candidateFilePath(resizedImageFilePath, "json")
+	return result, writeFile(result.ManifestPath, manifest)
+}
+
+// encodeCandidate encodes a copy of the resized image in the given format, with the quality
+// and encoder options of that format, returning the output along with its description.
+// The copy keeps format-specific processing, such as the palette reduction of GIFs, from
+// affecting the other candidates.
+func (i *imageResizer) encodeCandidate(format string) ([]byte, Candidate, error) {
+	resized := i.mw
+	i.mw = resized.CloneWand()
+	defer func() {
+		i.mw.Destroy()
+		i.mw = resized
+	}()
+	quality := i.quality(format)
+	if err := i.mw.SetImageCompressionQuality(uint(quality)); err != nil {
+		return nil, Candidate{}, errors.Wrapf(err, "setting image compression quality to %d", quality)
+	}
+	if err := i.setEncoderOptions(format); err != nil {
+		return nil, Candidate{}, err
+	}
+	if err := i.mw.SetImageFormat(format); err != nil {
+		return nil, Candidate{}, errors.Wrapf(err, "setting image format to %s", format)
+	}
+	tuned, err := i.tuneQuality(format)
+	if err != nil {
+		return nil, Candidate{}, err
+	}
+	blob, err := i.mw.GetImageBlob()
+	if err != nil {
+		return nil, Candidate{}, errors.Wrap(err, "encoding image")
+	}
+	return blob, Candidate{Format: format, Bytes: len(blob), Quality: tuned.Quality, SSIM: tuned.SSIM}, nil
+}
+
+// writeFile writes an encoded output to filePath.
+func writeFile(filePath string, data []byte) error {
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		return errors.Wrapf(err, "writing image %s", filePath)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_candidateFilePath(t *testing.T) {
+	require.Equal(t, "/path/to/dir/someImage_resized.webp", candidateFilePath("/path/to/dir/someImage_resized.jpg", "WEBP"))
+	require.Equal(t, "/path/to/dir/someImage_resized.json", candidateFilePath("/path/to/dir/someImage_resized", "json"))
+}
+
+func Test_resizeToSmallestFormat(t *testing.T) {
+	formatSizes := map[string]int{"JPEG": 3000, "WEBP": 2000, "AVIF": 2500, "GIF": 9000}
+	testCases := []struct {
+		name              string
+		candidateFormats  []string
+		keepAllCandidates bool
+		mockClosure       func(m *mockMagickWand)
+		expectedResult    Result
+		expectedFiles     []string
+		expectedManifest  *Manifest
+		expectedError     error
+	}{
+		{
+			name:             "keeps the smallest output",
+			candidateFormats: []string{"jpg", "webp", "avif"},
+			expectedResult: Result{
+				Path:    "someImage_resized.webp",
+				Quality: 80,
+				Candidates: []Candidate{
+					{Format: "JPEG", Path: "someImage_resized.jpeg", Bytes: 3000, Quality: 80},
+					{Format: "WEBP", Path: "someImage_resized.webp", Bytes: 2000, Quality: 80},
+					{Format: "AVIF", Path: "someImage_resized.avif", Bytes: 2500, Quality: 80},
+				},
+			},
+			expectedFiles: []string{"someImage_resized.webp"},
+		},
+		{
+			name:              "keeps all outputs with a manifest",
+			candidateFormats:  []string{"gif", "avif"},
+			keepAllCandidates: true,
+			expectedResult: Result{
+				Path:    "someImage_resized.avif",
+				Quality: 80,
+				Candidates: []Candidate{
+					{Format: "GIF", Path: "someImage_resized.gif", Bytes: 9000, Quality: 80},
+					{Format: "AVIF", Path: "someImage_resized.avif", Bytes: 2500, Quality: 80},
+				},
+				ManifestPath: "someImage_resized.json",
+			},
+			expectedFiles: []string{"someImage_resized.avif", "someImage_resized.gif", "someImage_resized.json"},
+			expectedManifest: &Manifest{
+				Smallest: "someImage_resized.avif",
+				Candidates: []Candidate{
+					{Format: "GIF", Path: "someImage_resized.gif", Bytes: 9000, Quality: 80},
+					{Format: "AVIF", Path: "someImage_resized.avif", Bytes: 2500, Quality: 80},
+				},
+			},
+		},
+		{
+			name:             "error when resizing image",
+			candidateFormats: []string{"webp"},
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("resizing image: resize image error"),
+		},
+		{
+			name:             "error when encoding candidate",
+			candidateFormats: []string{"webp"},
+			mockClosure: func(m *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			expectedError: errors.New("encoding candidate WEBP: encoding image: get image blob error"),
+		},
+		{
+			name:             "error when setting candidate format",
+			candidateFormats: []string{"avif"},
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetImageFormat = errors.New("set image format error")
+			},
+			expectedError: errors.New("encoding candidate AVIF: setting image format to AVIF: set image format error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m := new(mockMagickWand)
+			m.blobSize = func(quality, width, height uint) int { return formatSizes[m.imageFormat] }
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:                 m,
+				compressionQuality: 80,
+				candidateFormats:   tc.candidateFormats,
+				keepAllCandidates:  tc.keepAllCandidates,
+			}
+			result, err := ir.resizeToSmallestFormat(filepath.Join(dir, "someImage_resized.jpg"))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, inDir(dir, tc.expectedResult), result)
+				entries, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				var files []string
+				for _, entry := range entries {
+					files = append(files, entry.Name())
+				}
+				require.Equal(t, tc.expectedFiles, files)
+				if tc.expectedManifest != nil {
+					data, err := os.ReadFile(result.ManifestPath)
+					require.NoError(t, err)
+					var manifest Manifest
+					require.NoError(t, json.Unmarshal(data, &manifest))
+					expected := inDir(dir, Result{Path: tc.expectedManifest.Smallest, Candidates: tc.expectedManifest.Candidates})
+					require.Equal(t, Manifest{Smallest: expected.Path, Candidates: expected.Candidates}, manifest)
+				}
+			}
+		})
+	}
+}
+
+func Test_resizeToSmallestFormat_encoderOptionsPerCandidate(t *testing.T) {
+	dir := t.TempDir()
+	m, clone := new(mockMagickWand), new(mockMagickWand)
+	m.clone = clone
+	clone.blobSize = func(quality, width, height uint) int {
+		return map[string]int{"GIF": 9000, "PNG": 5000}[clone.imageFormat]
+	}
+	ir := &imageResizer{
+		mw:                 m,
+		compressionQuality: 80,
+		candidateFormats:   []string{"gif", "png"},
+		encoderOptions: EncoderOptions{
+			GIF: GIFOptions{Colors: 64},
+			PNG: PNGOptions{CompressionLevel: IntPtr(9)},
+		},
+	}
+	result, err := ir.resizeToSmallestFormat(filepath.Join(dir, "someImage_resized.jpg"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "someImage_resized.png"), result.Path)
+	// The shared resized image is neither quantized nor configured for any format.
+	require.Zero(t, m.quantizedColors)
+	require.Zero(t, m.quality)
+	require.Empty(t, m.options)
+	require.Equal(t, uint(64), clone.quantizedColors)
+	require.Equal(t, map[string]string{"png:compression-level": "9"}, clone.options)
+}
+
+// inDir returns a copy of result whose paths are relative to dir.
+func inDir(dir string, result Result) Result {
+	result.Path = filepath.Join(dir, result.Path)
+	if result.ManifestPath != "" {
+		result.ManifestPath = filepath.Join(dir, result.ManifestPath)
+	}
+	candidates := make([]Candidate, len(result.Candidates))
+	for n, candidate := range result.Candidates {
+		candidate.Path = filepath.Join(dir, candidate.Path)
+		candidates[n] = candidate
+	}
+	result.Candidates = candidates
+	return result
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// colorsMaxSide is the largest side, in pixels, of the copy of the image colors are
+	// analyzed from. It keeps quantization fast while preserving the proportion of each color.
+	colorsMaxSide = 100
+	// defaultPaletteSize is the number of palette colors reported when none is set.
+	defaultPaletteSize = 5
+)
+
+// Colors describes the colors of an image.
+type Colors struct {
+	Average  string   // Average color of the image, as a hex triplet such as "#1e90ff".
+	Dominant string   // Most common color of the palette, as a hex triplet.
+	Palette  []Swatch // Colors the image is reduced to, from the most to the least common.
+}
+
+// Swatch is a color of the palette of an image.
+type Swatch struct {
+	Color string  // Color, as a hex triplet such as "#1e90ff".
+	Share float64 // Percentage of the visible pixels of the image reduced to the color.
+}
+
+// Colors reads a small copy of the first frame of the image located at imageFilePath and
+// analyzes its colors, with a palette of as many colors as set by WithColors, or 5 if none was.
+func (i *imageResizer) Colors(imageFilePath string) (_ Colors, err error) {
+	defer recoverPanic(&err)
+	mw, err := i.readSmall(imageFilePath, colorsMaxSide)
+	if err != nil {
+		return Colors{}, err
+	}
+	defer mw.Destroy()
+	return i.colors(mw)
+}
+
+// resizedColors returns the colors of the resized image, if requested.
+func (i *imageResizer) resizedColors() (Colors, error) {
+	if i.paletteSize <= 0 {
+		return Colors{}, nil
+	}
+	mw := i.mw.CloneImage()
+	defer mw.Destroy()
+	colors, err := i.colors(mw)
+	if err != nil {
+		return Colors{}, errors.Wrap(err, "analyzing colors")
+	}
+	return colors, nil
+}
+
+// colors analyzes the colors of the current image of the wand, which is scaled down and
+// quantized in the process.
+func (i *imageResizer) colors(mw magickWand) (Colors, error) {
+	paletteSize := i.paletteSize
+	if paletteSize <= 0 {
+		paletteSize = defaultPaletteSize
+	}
+	rgba, _, _, err := tinyRGBA(mw, colorsMaxSide, i.filterType)
+	if err != nil {
+		return Colors{}, err
+	}
+	colors := Colors{Average: averageColor(rgba)}
+	if err := mw.Quantize(uint(paletteSize), false); err != nil {
+		return Colors{}, errors.Wrapf(err, "reducing image to %d colors", paletteSize)
+	}
+	colors.Palette = palette(mw.Histogram())
+	if len(colors.Palette) > 0 {
+		colors.Dominant = colors.Palette[0].Color
+	}
+	return colors, nil
+}
+
+// averageColor returns the average of the given RGBA pixels, weighted by their opacity, as a
+// hex triplet. It is empty when every pixel is fully transparent.
+func averageColor(rgba []byte) string {
+	var sum [3]float64
+	var weight float64
+	for n := 0; n+3 < len(rgba); n += 4 {
+		alpha := float64(rgba[n+3])
+		for c := range sum {
+			sum[c] += float64(rgba[n+c]) * alpha
+		}
+		weight += alpha
+	}
+	if weight == 0 {
+		return ""
+	}
+	return hexColor(
+		uint8(roundHalfUp(sum[0]/weight)),
+		uint8(roundHalfUp(sum[1]/weight)),
+		uint8(roundHalfUp(sum[2]/weight)),
+	)
+}
+
+// palette turns the histogram of a quantized image into swatches, from the most to the least
+// common color. Fully transparent pixels are left out, as they have no visible color.
+func palette(histogram []histogramColor) []Swatch {
+	var total uint
+	counts := make(map[string]uint)
+	for _, color := range histogram {
+		if color.RGBA[3] == 0 {
+			continue
+		}
+		// Colors differing only in opacity count as the same swatch.
+		counts[hexColor(color.RGBA[0], color.RGBA[1], color.RGBA[2])] += color.Count
+		total += color.Count
+	}
+	swatches := make([]Swatch, 0, len(counts))
+	for color, count := range counts {
+		swatches = append(swatches, Swatch{Color: color, Share: float64(count) * 100 / float64(total)})
+	}
+	sort.Slice(swatches, func(a, b int) bool {
+		if swatches[a].Share != swatches[b].Share {
+			return swatches[a].Share > swatches[b].Share
+		}
+		return swatches[a].Color < swatches[b].Color
+	})
+	return swatches
+}
+
+// hexColor formats a color as a hex triplet, such as "#1e90ff".
+func hexColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
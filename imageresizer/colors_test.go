@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColors(t *testing.T) {
+	histogram := []histogramColor{
+		{RGBA: [4]uint8{30, 144, 255, 255}, Count: 2500},
+		{RGBA: [4]uint8{255, 255, 255, 255}, Count: 5000},
+		{RGBA: [4]uint8{0, 0, 0, 0}, Count: 1000},
+		{RGBA: [4]uint8{255, 255, 255, 128}, Count: 2500},
+	}
+	testCases := []struct {
+		name                string
+		paletteSize         int
+		mockClosure         func(m *mockMagickWand)
+		expectedColors      Colors
+		expectedQuantizedTo uint
+		expectedError       error
+	}{
+		{
+			name: "five colors by default",
+			expectedColors: Colors{
+				Average:  "#1e90ff",
+				Dominant: "#ffffff",
+				Palette: []Swatch{
+					{Color: "#ffffff", Share: 75},
+					{Color: "#1e90ff", Share: 25},
+				},
+			},
+			expectedQuantizedTo: 5,
+		},
+		{
+			name:        "palette size",
+			paletteSize: 2,
+			expectedColors: Colors{
+				Average:  "#1e90ff",
+				Dominant: "#ffffff",
+				Palette: []Swatch{
+					{Color: "#ffffff", Share: 75},
+					{Color: "#1e90ff", Share: 25},
+				},
+			},
+			expectedQuantizedTo: 2,
+		},
+		{
+			name: "error when setting jpeg size hint",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting jpeg:size to 100x100: set option error"),
+		},
+		{
+			name: "error when reading image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image someImage.jpg: read image error"),
+		},
+		{
+			name: "error when scaling image down",
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("scaling image down to 100x71: resize image error"),
+		},
+		{
+			name: "error when exporting pixels",
+			mockClosure: func(m *mockMagickWand) {
+				m.errExportRGBA = errors.New("export rgba error")
+			},
+			expectedError: errors.New("exporting pixels: export rgba error"),
+		},
+		{
+			name: "error when quantizing image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errQuantize = errors.New("quantize error")
+			},
+			expectedError: errors.New("reducing image to 5 colors: quantize error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{width: 1200, height: 850, rgba: [4]byte{30, 144, 255, 255}, histogram: histogram}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				paletteSize:   tc.paletteSize,
+				newMagickWand: func() magickWand { return m },
+			}
+			colors, err := ir.Colors("someImage.jpg")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedColors, colors)
+				require.Equal(t, tc.expectedQuantizedTo, m.quantizedColors)
+				require.Equal(t, map[string]string{"jpeg:size": "100x100"}, m.options)
+				require.Equal(t, "someImage.jpg[0]", m.readImage, "only the first frame is read")
+			}
+		})
+	}
+}
+
+func TestWithColors(t *testing.T) {
+	testCases := []struct {
+		name                string
+		paletteSize         int
+		expectedPaletteSize int
+	}{
+		{
+			name:                "palette size",
+			paletteSize:         8,
+			expectedPaletteSize: 8,
+		},
+		{
+			name:                "zero disables the analysis",
+			paletteSize:         0,
+			expectedPaletteSize: 0,
+		},
+		{
+			name:                "negative disables the analysis",
+			paletteSize:         -1,
+			expectedPaletteSize: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{width: 20, height: 10}
+			ir := &imageResizer{mw: m}
+			WithColors(tc.paletteSize)(ir)
+			require.Equal(t, tc.expectedPaletteSize, ir.paletteSize)
+			_, err := ir.resizedColors()
+			require.NoError(t, err)
+			require.Equal(t, uint(tc.expectedPaletteSize), m.quantizedColors)
+		})
+	}
+}
+
+func Test_resizedColors(t *testing.T) {
+	m := &mockMagickWand{width: 20, height: 10, rgba: [4]byte{0, 0, 255, 255}}
+	ir := &imageResizer{mw: m}
+	colors, err := ir.resizedColors()
+	require.NoError(t, err)
+	require.Equal(t, Colors{}, colors)
+
+	ir.paletteSize = 3
+	colors, err = ir.resizedColors()
+	require.NoError(t, err)
+	require.Equal(t, Colors{Average: "#0000ff", Dominant: "#0000ff", Palette: []Swatch{{Color: "#0000ff", Share: 100}}}, colors)
+	require.Equal(t, uint(3), m.quantizedColors)
+	require.Equal(t, 0, m.resizes, "images smaller than the analyzed copy are not scaled")
+
+	m.errQuantize = errors.New("quantize error")
+	_, err = ir.resizedColors()
+	require.EqualError(t, err, "analyzing colors: reducing image to 3 colors: quantize error")
+}
+
+func Test_averageColor(t *testing.T) {
+	testCases := []struct {
+		name          string
+		rgba          []byte
+		expectedColor string
+	}{
+		{
+			name:          "opaque pixels",
+			rgba:          []byte{255, 0, 0, 255, 0, 0, 255, 255},
+			expectedColor: "#800080",
+		},
+		{
+			name:          "weighted by opacity",
+			rgba:          []byte{255, 0, 0, 255, 0, 0, 255, 85},
+			expectedColor: "#bf0040",
+		},
+		{
+			name:          "transparent pixels",
+			rgba:          []byte{255, 0, 0, 0, 0, 0, 255, 0},
+			expectedColor: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedColor, averageColor(tc.rgba))
+		})
+	}
+}
+
+func Test_palette(t *testing.T) {
+	swatches := palette([]histogramColor{
+		{RGBA: [4]uint8{0, 0, 0, 255}, Count: 10},
+		{RGBA: [4]uint8{255, 0, 0, 255}, Count: 30},
+		{RGBA: [4]uint8{0, 255, 0, 255}, Count: 10},
+		{RGBA: [4]uint8{9, 9, 9, 0}, Count: 50},
+	})
+	require.Equal(t, []Swatch{
+		{Color: "#ff0000", Share: 60},
+		{Color: "#000000", Share: 20},
+		{Color: "#00ff00", Share: 20},
+	}, swatches)
+	require.Empty(t, palette([]histogramColor{{RGBA: [4]uint8{9, 9, 9, 0}, Count: 50}}))
+}
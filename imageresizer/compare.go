@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOutsideTolerance is returned by Comparison.Within when images differ more than tolerated.
+var ErrOutsideTolerance = errors.New("images differ beyond tolerance")
+
+// CompareOptions sets how Compare compares images.
+type CompareOptions struct {
+	Fuzz          float64 // Percentage of the color range within which pixels count as equal for the absolute error; zero for exact matches.
+	DiffImagePath string  // Path a diff image is written to, highlighting the differing pixels in red; empty for none.
+}
+
+// Comparison holds metrics telling how much an image differs from a reference image.
+type Comparison struct {
+	PSNR          float64 // Peak signal-to-noise ratio, in dB; +Inf for identical images.
+	SSIM          float64 // Structural similarity index, 1 for identical images.
+	RMSE          float64 // Root mean squared error, from 0 for identical images to 1.
+	AbsoluteError int     // Number of pixels that differ by more than the fuzz.
+}
+
+// Tolerance sets how much an image may differ from a reference image. Zero fields leave their
+// metric unchecked; to require identical pixels, check Comparison.AbsoluteError instead.
+type Tolerance struct {
+	MinPSNR          float64 // Lowest acceptable peak signal-to-noise ratio, in dB.
+	MinSSIM          float64 // Lowest acceptable structural similarity index.
+	MaxRMSE          float64 // Highest acceptable root mean squared error.
+	MaxAbsoluteError int     // Highest acceptable number of differing pixels.
+}
+
+// Within returns an error wrapping ErrOutsideTolerance and listing every metric out of the
+// tolerance, if any. It lets tests assert that an output matches a reference closely enough:
+//
+//	require.NoError(t, comparison.Within(imageresizer.Tolerance{MinSSIM: 0.99}))
+func (c Comparison) Within(t Tolerance) error {
+	var failures []string
+	if t.MinPSNR > 0 && c.PSNR < t.MinPSNR {
+		failures = append(failures, fmt.Sprintf("PSNR %.2f dB below %g", c.PSNR, t.MinPSNR))
+	}
+	if t.MinSSIM > 0 && c.SSIM < t.MinSSIM {
+		failures = append(failures, fmt.Sprintf("SSIM %.4f below %g", c.SSIM, t.MinSSIM))
+	}
+	if t.MaxRMSE > 0 && c.RMSE > t.MaxRMSE {
+		failures = append(failures, fmt.Sprintf("RMSE %.4f above %g", c.RMSE, t.MaxRMSE))
+	}
+	if t.MaxAbsoluteError > 0 && c.AbsoluteError > t.MaxAbsoluteError {
+		failures = append(failures, fmt.Sprintf("%d differing pixels above %d", c.AbsoluteError, t.MaxAbsoluteError))
+	}
+	if len(failures) > 0 {
+		return errors.Wrap(ErrOutsideTolerance, strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+// Compare reads the first frames of the images located at imageFilePath and referenceFilePath
+// and measures how much the former differs from the latter, with the fuzz and diff image set
+// by WithCompareOptions.
+func (i *imageResizer) Compare(imageFilePath, referenceFilePath string) (_ Comparison, err error) {
+	defer recoverPanic(&err)
+	mw, err := i.readFirstFrame(imageFilePath)
+	if err != nil {
+		return Comparison{}, err
+	}
+	defer mw.Destroy()
+	reference, err := i.readFirstFrame(referenceFilePath)
+	if err != nil {
+		return Comparison{}, err
+	}
+	defer reference.Destroy()
+	width, height := mw.GetImageWidth(), mw.GetImageHeight()
+	if refWidth, refHeight := reference.GetImageWidth(), reference.GetImageHeight(); width != refWidth || height != refHeight {
+		return Comparison{}, fmt.Errorf("images differ in size: %dx%d and %dx%d", width, height, refWidth, refHeight)
+	}
+	var comparison Comparison
+	if comparison.RMSE, err = mw.Distortion(reference, metricRootMeanSquaredError); err != nil {
+		return Comparison{}, errors.Wrap(err, "measuring RMSE")
+	}
+	comparison.PSNR = math.Inf(1)
+	// The PSNR of identical images is undefined, and reported inconsistently by ImageMagick.
+	if comparison.RMSE > 0 {
+		if comparison.PSNR, err = mw.Distortion(reference, metricPeakSignalToNoiseRatio); err != nil {
+			return Comparison{}, errors.Wrap(err, "measuring PSNR")
+		}
+	}
+	dssim, err := mw.Distortion(reference, metricStructuralDissimilarity)
+	if err != nil {
+		return Comparison{}, errors.Wrap(err, "measuring SSIM")
+	}
+	comparison.SSIM = ssimFromDSSIM(dssim)
+	if fuzz := i.compareOptions.Fuzz; fuzz > 0 {
+		if err := mw.SetFuzz(fuzz); err != nil {
+			return Comparison{}, errors.Wrapf(err, "setting fuzz to %g%%", fuzz)
+		}
+	}
+	differing, err := mw.Distortion(reference, metricAbsoluteError)
+	if err != nil {
+		return Comparison{}, errors.Wrap(err, "measuring absolute error")
+	}
+	comparison.AbsoluteError = int(math.Round(differing))
+	if path := i.compareOptions.DiffImagePath; path != "" {
+		if err := writeDiffImage(mw, reference, path); err != nil {
+			return Comparison{}, err
+		}
+	}
+	return comparison, nil
+}
+
+// readFirstFrame reads the first frame of the image located at imageFilePath into a new wand,
+// which the caller must destroy.
+func (i *imageResizer) readFirstFrame(imageFilePath string) (magickWand, error) {
+	mw := i.newMagickWand()
+	if err := mw.ReadImage(imageFilePath + firstFrameSelector); err != nil {
+		mw.Destroy()
+		return nil, errors.Wrapf(err, "reading image %s", imageFilePath)
+	}
+	mw.SetIteratorIndex(0)
+	return mw, nil
+}
+
+// writeDiffImage writes an image highlighting the pixels of the wand's image that differ from
+// the reference's to path.
+func writeDiffImage(mw, reference magickWand, path string) error {
+	diff, err := mw.DiffImage(reference)
+	if err != nil {
+		return errors.Wrap(err, "creating diff image")
+	}
+	defer diff.Destroy()
+	if err := diff.WriteImage(path); err != nil {
+		return errors.Wrapf(err, "writing diff image %s", path)
+	}
+	return nil
+}
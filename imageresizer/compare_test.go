@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	distortions := map[metricType]float64{
+		metricRootMeanSquaredError:    0.02,
+		metricPeakSignalToNoiseRatio:  33.98,
+		metricStructuralDissimilarity: 0.015,
+		metricAbsoluteError:           1250,
+	}
+	testCases := []struct {
+		name               string
+		compareOptions     CompareOptions
+		mockClosure        func(image, reference, diff *mockMagickWand)
+		expectedComparison Comparison
+		expectedFuzz       float64
+		expectedDiffImage  []string
+		expectedError      error
+	}{
+		{
+			name:               "metrics",
+			expectedComparison: Comparison{PSNR: 33.98, SSIM: 0.97, RMSE: 0.02, AbsoluteError: 1250},
+		},
+		{
+			name: "identical images",
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				image.distortions = map[metricType]float64{}
+			},
+			expectedComparison: Comparison{PSNR: math.Inf(1), SSIM: 1},
+		},
+		{
+			name:               "fuzz and diff image",
+			compareOptions:     CompareOptions{Fuzz: 5, DiffImagePath: "diff.png"},
+			expectedComparison: Comparison{PSNR: 33.98, SSIM: 0.97, RMSE: 0.02, AbsoluteError: 1250},
+			expectedFuzz:       5,
+			expectedDiffImage:  []string{"diff.png"},
+		},
+		{
+			name: "error when reading image",
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				image.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image someImage.png: read image error"),
+		},
+		{
+			name: "error when reading reference",
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				reference.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image reference.png: read image error"),
+		},
+		{
+			name: "error when sizes differ",
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				reference.width = 1199
+			},
+			expectedError: errors.New("images differ in size: 1200x850 and 1199x850"),
+		},
+		{
+			name: "error when measuring",
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				image.errDistortion = errors.New("distortion error")
+			},
+			expectedError: errors.New("measuring RMSE: distortion error"),
+		},
+		{
+			name:           "error when setting fuzz",
+			compareOptions: CompareOptions{Fuzz: 5},
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				image.errSetFuzz = errors.New("set fuzz error")
+			},
+			expectedError: errors.New("setting fuzz to 5%: set fuzz error"),
+		},
+		{
+			name:           "error when creating diff image",
+			compareOptions: CompareOptions{DiffImagePath: "diff.png"},
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				image.errDiffImage = errors.New("diff image error")
+			},
+			expectedError: errors.New("creating diff image: diff image error"),
+		},
+		{
+			name:           "error when writing diff image",
+			compareOptions: CompareOptions{DiffImagePath: "diff.png"},
+			mockClosure: func(image, reference, diff *mockMagickWand) {
+				diff.errWriteImage = errors.New("write image error")
+			},
+			expectedError: errors.New("writing diff image diff.png: write image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := new(mockMagickWand)
+			image := &mockMagickWand{width: 1200, height: 850, distortions: distortions, diff: diff}
+			reference := &mockMagickWand{width: 1200, height: 850}
+			if tc.mockClosure != nil {
+				tc.mockClosure(image, reference, diff)
+			}
+			wands := []*mockMagickWand{image, reference}
+			ir := &imageResizer{
+				compareOptions: tc.compareOptions,
+				newMagickWand: func() magickWand {
+					mw := wands[0]
+					wands = wands[1:]
+					return mw
+				},
+			}
+			comparison, err := ir.Compare("someImage.png", "reference.png")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedComparison, comparison)
+				require.Equal(t, tc.expectedFuzz, image.fuzz)
+				require.Equal(t, tc.expectedDiffImage, diff.writtenImage)
+				require.Equal(t, "someImage.png[0]", image.readImage, "only the first frame is read")
+				require.Equal(t, "reference.png[0]", reference.readImage)
+			}
+		})
+	}
+}
+
+func TestComparisonWithin(t *testing.T) {
+	comparison := Comparison{PSNR: 33.98, SSIM: 0.97, RMSE: 0.02, AbsoluteError: 1250}
+	testCases := []struct {
+		name          string
+		tolerance     Tolerance
+		expectedError error
+	}{
+		{
+			name: "no tolerance",
+		},
+		{
+			name:      "within tolerance",
+			tolerance: Tolerance{MinPSNR: 30, MinSSIM: 0.95, MaxRMSE: 0.05, MaxAbsoluteError: 2000},
+		},
+		{
+			name:          "beyond tolerance",
+			tolerance:     Tolerance{MinPSNR: 35, MinSSIM: 0.99, MaxRMSE: 0.01, MaxAbsoluteError: 1000},
+			expectedError: errors.New("PSNR 33.98 dB below 35, SSIM 0.9700 below 0.99, RMSE 0.0200 above 0.01, 1250 differing pixels above 1000: images differ beyond tolerance"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := comparison.Within(tc.tolerance)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.ErrorIs(t, err, ErrOutsideTolerance)
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
+)
+
+// ChromaSubsampling is the resolution at which the color of JPEG images is stored,
+// relative to their brightness.
+type ChromaSubsampling int
+
+const (
+	CHROMA_SUBSAMPLING_DEFAULT ChromaSubsampling = iota // Let the encoder decide, based on the quality.
+	CHROMA_SUBSAMPLING_444                              // Full color resolution.
+	CHROMA_SUBSAMPLING_422                              // Half the horizontal color resolution.
+	CHROMA_SUBSAMPLING_420                              // Half the horizontal and vertical color resolution.
+)
+
+// samplingFactors maps each chroma subsampling to the sampling factors of the Y, Cb and Cr components.
+var samplingFactors = map[ChromaSubsampling]string{
+	CHROMA_SUBSAMPLING_444: "1x1,1x1,1x1",
+	CHROMA_SUBSAMPLING_422: "2x1,1x1,1x1",
+	CHROMA_SUBSAMPLING_420: "2x2,1x1,1x1",
+}
+
+// setEncoderOptions configures how the encoder of the given format writes the output. Most of
+// these settings belong to the wand rather than to its images, and are only picked up by the
+// encoders of the formats they apply to, e.g. the chroma subsampling is ignored when writing a PNG.
+func (i *imageResizer) setEncoderOptions(format string) error {
+	if i.progressiveJPEG || i.interlacedPNG {
+		// Plane interlacing gives progressive JPEGs and Adam7 interlaced PNGs. The scheme
+		// belongs to the wand, so it is reset for the formats it is not requested for.
+		scheme := interlaceUndefined
+		if (format == "JPEG" && i.progressiveJPEG) || (format == "PNG" && i.interlacedPNG) {
+			scheme = interlacePlane
+		}
+		if err := i.mw.SetInterlaceScheme(scheme); err != nil {
+			return errors.Wrap(err, "setting interlace scheme")
+		}
+	}
+	if factors, ok := samplingFactors[i.chromaSubsampling]; ok {
+		if err := i.mw.SetOption("jpeg:sampling-factor", factors); err != nil {
+			return errors.Wrapf(err, "setting sampling factors to %s", factors)
+		}
+	}
+	if i.optimizeCoding {
+		if err := i.mw.SetOption("jpeg:optimize-coding", "true"); err != nil {
+			return errors.Wrap(err, "enabling optimized coding")
+		}
+	}
+	options := i.formatOptions(format)
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := i.mw.SetOption(key, options[key]); err != nil {
+			return errors.Wrapf(err, "setting %s to %s", key, options[key])
+		}
+	}
+	if colors := i.encoderOptions.GIF.Colors; format == "GIF" && colors > 0 {
+		if err := i.mw.Quantize(uint(colors), i.encoderOptions.GIF.Dither); err != nil {
+			return errors.Wrapf(err, "reducing palette to %d colors", colors)
+		}
+	}
+	return nil
+}
+
+// EncoderOptions holds settings specific to each output format. Each section is applied only
+// when writing its format, so that a single configuration suits every format it may emit.
+type EncoderOptions struct {
+	WebP WebPOptions // Settings applied when writing WebP.
+	AVIF AVIFOptions // Settings applied when writing AVIF.
+	PNG  PNGOptions  // Settings applied when writing PNG.
+	GIF  GIFOptions  // Settings applied when writing GIF.
+}
+
+// WebPOptions holds the settings of the WebP encoder.
+type WebPOptions struct {
+	Lossless     bool // Whether to encode losslessly, ignoring the compression quality.
+	NearLossless int  // Near-lossless preprocessing, from 1 (strongest) to 100 (none); zero disables it.
+	Method       *int // Compression effort, from 0 (fastest) to 6 (smallest); nil for the encoder's default.
+}
+
+// AVIFOptions holds the settings of the AVIF encoder.
+type AVIFOptions struct {
+	Quality int  // Compression quality, from 1 to 100, used instead of the one set by WithCompressionQuality; zero keeps it.
+	Speed   *int // Encoding speed, from 0 (slowest, smallest) to 9 (fastest); nil for the encoder's default.
+}
+
+// PNGOptions holds the settings of the PNG encoder.
+type PNGOptions struct {
+	CompressionLevel *int // zlib compression level, from 0 (none) to 9 (smallest); nil for the encoder's default.
+	Filter           *int // Row filter, from 0 (none) to 4 (Paeth), or 5 for adaptive filtering; nil for the encoder's default.
+}
+
+// GIFOptions holds the settings of the GIF encoder.
+type GIFOptions struct {
+	Colors int  // Maximum number of colors in the palette, from 2 to 256; zero keeps the encoder's default.
+	Dither bool // Whether to dither the colors reduced to the palette.
+}
+
+// FormatOf returns the format in which ImageMagick writes the given file, which follows its extension.
+func FormatOf(filePath string) string {
+	return wand.FormatOf(filePath)
+}
+
+// outputFormatOf returns the format in which the given output is written: the one following its
+// extension or, as ImageMagick does for outputs without one, the format of the current image.
+func (i *imageResizer) outputFormatOf(filePath string) string {
+	if format := FormatOf(filePath); format != "" {
+		return format
+	}
+	return strings.ToUpper(i.mw.GetImageFormat())
+}
+
+// quality returns the compression quality used to write the given format.
+func (i *imageResizer) quality(format string) int {
+	if format == "AVIF" && i.encoderOptions.AVIF.Quality > 0 {
+		return i.encoderOptions.AVIF.Quality
+	}
+	return i.compressionQuality
+}
+
+// formatOptions returns the encoder options (defines) specific to the given format.
+func (i *imageResizer) formatOptions(format string) map[string]string {
+	options := make(map[string]string)
+	switch format {
+	case "WEBP":
+		webp := i.encoderOptions.WebP
+		if webp.Lossless {
+			options["webp:lossless"] = "true"
+		}
+		if webp.NearLossless > 0 {
+			options["webp:near-lossless"] = strconv.Itoa(webp.NearLossless)
+		}
+		if webp.Method != nil {
+			options["webp:method"] = strconv.Itoa(*webp.Method)
+		}
+	case "AVIF":
+		if speed := i.encoderOptions.AVIF.Speed; speed != nil {
+			options["heic:speed"] = strconv.Itoa(*speed)
+		}
+	case "PNG":
+		png := i.encoderOptions.PNG
+		if png.CompressionLevel != nil {
+			options["png:compression-level"] = strconv.Itoa(*png.CompressionLevel)
+		}
+		if png.Filter != nil {
+			options["png:compression-filter"] = strconv.Itoa(*png.Filter)
+		}
+	}
+	return options
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_setEncoderOptions(t *testing.T) {
+	testCases := []struct {
+		name              string
+		progressiveJPEG   bool
+		interlacedPNG     bool
+		chromaSubsampling ChromaSubsampling
+		optimizeCoding    bool
+		format            string
+		encoderOptions    EncoderOptions
+		mockClosure       func(m *mockMagickWand)
+		expectedOptions   map[string]string
+		expectedInterlace interlaceType
+		expectedError     error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:              "all options",
+			progressiveJPEG:   true,
+			chromaSubsampling: CHROMA_SUBSAMPLING_420,
+			optimizeCoding:    true,
+			expectedOptions: map[string]string{
+				"jpeg:sampling-factor": "2x2,1x1,1x1",
+				"jpeg:optimize-coding": "true",
+			},
+			expectedInterlace: interlaceUndefined,
+		},
+		{
+			name:              "progressive jpeg",
+			progressiveJPEG:   true,
+			format:            "JPEG",
+			expectedInterlace: interlacePlane,
+		},
+		{
+			name:              "progressive jpeg only when writing png",
+			progressiveJPEG:   true,
+			format:            "PNG",
+			expectedInterlace: interlaceUndefined,
+		},
+		{
+			name:              "interlaced png",
+			interlacedPNG:     true,
+			format:            "PNG",
+			expectedInterlace: interlacePlane,
+		},
+		{
+			name:              "interlaced png only when writing jpeg",
+			interlacedPNG:     true,
+			format:            "JPEG",
+			expectedInterlace: interlaceUndefined,
+		},
+		{
+			name:   "webp options",
+			format: "WEBP",
+			encoderOptions: EncoderOptions{
+				WebP: WebPOptions{Lossless: true, NearLossless: 60, Method: IntPtr(0)},
+				PNG:  PNGOptions{CompressionLevel: IntPtr(9)},
+			},
+			expectedOptions: map[string]string{
+				"webp:lossless":      "true",
+				"webp:near-lossless": "60",
+				"webp:method":        "0",
+			},
+		},
+		{
+			name:   "avif options",
+			format: "AVIF",
+			encoderOptions: EncoderOptions{
+				AVIF: AVIFOptions{Speed: IntPtr(6)},
+			},
+			expectedOptions: map[string]string{
+				"heic:speed": "6",
+			},
+		},
+		{
+			name:   "png options",
+			format: "PNG",
+			encoderOptions: EncoderOptions{
+				WebP: WebPOptions{Lossless: true},
+				PNG:  PNGOptions{CompressionLevel: IntPtr(9), Filter: IntPtr(5)},
+			},
+			expectedOptions: map[string]string{
+				"png:compression-level":  "9",
+				"png:compression-filter": "5",
+			},
+		},
+		{
+			name:   "error when reducing gif palette",
+			format: "GIF",
+			encoderOptions: EncoderOptions{
+				GIF: GIFOptions{Colors: 64, Dither: true},
+			},
+			mockClosure: func(m *mockMagickWand) {
+				m.errQuantize = errors.New("quantize error")
+			},
+			expectedError: errors.New("reducing palette to 64 colors: quantize error"),
+		},
+		{
+			name:   "error when setting format option",
+			format: "PNG",
+			encoderOptions: EncoderOptions{
+				PNG: PNGOptions{Filter: IntPtr(5)},
+			},
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting png:compression-filter to 5: set option error"),
+		},
+		{
+			name:            "error when setting interlace scheme",
+			progressiveJPEG: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetInterlaceScheme = errors.New("set interlace scheme error")
+			},
+			expectedError: errors.New("setting interlace scheme: set interlace scheme error"),
+		},
+		{
+			name:              "error when setting sampling factors",
+			chromaSubsampling: CHROMA_SUBSAMPLING_444,
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting sampling factors to 1x1,1x1,1x1: set option error"),
+		},
+		{
+			name:           "error when enabling optimized coding",
+			optimizeCoding: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("enabling optimized coding: set option error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:                m,
+				progressiveJPEG:   tc.progressiveJPEG,
+				interlacedPNG:     tc.interlacedPNG,
+				chromaSubsampling: tc.chromaSubsampling,
+				optimizeCoding:    tc.optimizeCoding,
+				encoderOptions:    tc.encoderOptions,
+			}
+			err := ir.setEncoderOptions(tc.format)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedInterlace, m.interlaceScheme)
+				if len(tc.expectedOptions) == 0 {
+					require.Empty(t, m.options)
+					return
+				}
+				require.Equal(t, tc.expectedOptions, m.options)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	require.Equal(t, "JPEG", FormatOf("path/to/file_resized.jpg"))
+	require.Equal(t, "WEBP", FormatOf("path/to/file_resized.WebP"))
+	require.Equal(t, "", FormatOf("path/to/file_resized"))
+}
+
+func Test_outputFormatOf(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filePath       string
+		expectedOutput string
+	}{
+		{
+			name:           "extension",
+			filePath:       "path/to/file_resized.jpg",
+			expectedOutput: "JPEG",
+		},
+		{
+			name:           "no extension",
+			filePath:       "path/to/file_resized",
+			expectedOutput: "PNG",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := &imageResizer{mw: &mockMagickWand{format: "png"}}
+			require.Equal(t, tc.expectedOutput, ir.outputFormatOf(tc.filePath))
+		})
+	}
+}
+
+func Test_quality(t *testing.T) {
+	ir := &imageResizer{
+		compressionQuality: 80,
+		encoderOptions:     EncoderOptions{AVIF: AVIFOptions{Quality: 50}},
+	}
+	require.Equal(t, 80, ir.quality("JPEG"))
+	require.Equal(t, 50, ir.quality("AVIF"))
+}
@@ -4,41 +4,52 @@
 
 package imageresizer
 
-import "gopkg.in/gographics/imagick.v3/imagick"
+import "fmt"
 
+// FilterType is the filter used to resize images, among those of ImageMagick. Its values are
+// those of ImageMagick 7's FilterType, whatever the backend, so that they can be stored or sent
+// as numbers.
 type FilterType int
 
 const (
-	FILTER_UNDEFINED      FilterType = FilterType(imagick.FILTER_UNDEFINED)
-	FILTER_POINT          FilterType = FilterType(imagick.FILTER_POINT)
-	FILTER_BOX            FilterType = FilterType(imagick.FILTER_BOX)
-	FILTER_TRIANGLE       FilterType = FilterType(imagick.FILTER_TRIANGLE)
-	FILTER_HERMITE        FilterType = FilterType(imagick.FILTER_HERMITE)
-	FILTER_HANNING        FilterType = FilterType(imagick.FILTER_HANNING)
-	FILTER_HAMMING        FilterType = FilterType(imagick.FILTER_HAMMING)
-	FILTER_BLACKMAN       FilterType = FilterType(imagick.FILTER_BLACKMAN)
-	FILTER_GAUSSIAN       FilterType = FilterType(imagick.FILTER_GAUSSIAN)
-	FILTER_QUADRATIC      FilterType = FilterType(imagick.FILTER_QUADRATIC)
-	FILTER_CUBIC          FilterType = FilterType(imagick.FILTER_CUBIC)
-	FILTER_CATROM         FilterType = FilterType(imagick.FILTER_CATROM)
-	FILTER_MITCHELL       FilterType = FilterType(imagick.FILTER_MITCHELL)
-	FILTER_JINC           FilterType = FilterType(imagick.FILTER_JINC)
-	FILTER_SINC           FilterType = FilterType(imagick.FILTER_SINC)
-	FILTER_SINC_FAST      FilterType = FilterType(imagick.FILTER_SINC_FAST)
-	FILTER_KAISER         FilterType = FilterType(imagick.FILTER_KAISER)
-	FILTER_WELSH          FilterType = FilterType(imagick.FILTER_WELSH)
-	FILTER_PARZEN         FilterType = FilterType(imagick.FILTER_PARZEN)
-	FILTER_BOHMAN         FilterType = FilterType(imagick.FILTER_BOHMAN)
-	FILTER_BARTLETT       FilterType = FilterType(imagick.FILTER_BARTLETT)
-	FILTER_LAGRANGE       FilterType = FilterType(imagick.FILTER_LAGRANGE)
-	FILTER_LANCZOS        FilterType = FilterType(imagick.FILTER_LANCZOS)
-	FILTER_LANCZOS_SHARP  FilterType = FilterType(imagick.FILTER_LANCZOS_SHARP)
-	FILTER_LANCZOS2       FilterType = FilterType(imagick.FILTER_LANCZOS2)
-	FILTER_LANCZOS2_SHARP FilterType = FilterType(imagick.FILTER_LANCZOS2_SHARP)
-	FILTER_ROBIDOUX       FilterType = FilterType(imagick.FILTER_ROBIDOUX)
-	FILTER_ROBIDOUX_SHARP FilterType = FilterType(imagick.FILTER_ROBIDOUX_SHARP)
-	FILTER_COSINE         FilterType = FilterType(imagick.FILTER_COSINE)
-	FILTER_SPLINE         FilterType = FilterType(imagick.FILTER_SPLINE)
-	FILTER_SENTINEL       FilterType = FilterType(imagick.FILTER_SENTINEL)
-	FILTER_LANCZOS_RADIUS FilterType = FilterType(imagick.FILTER_LANCZOS_RADIUS)
+	FILTER_UNDEFINED      FilterType = 0
+	FILTER_POINT          FilterType = 1
+	FILTER_BOX            FilterType = 2
+	FILTER_TRIANGLE       FilterType = 3
+	FILTER_HERMITE        FilterType = 4
+	FILTER_HANNING        FilterType = 5
+	FILTER_HAMMING        FilterType = 6
+	FILTER_BLACKMAN       FilterType = 7
+	FILTER_GAUSSIAN       FilterType = 8
+	FILTER_QUADRATIC      FilterType = 9
+	FILTER_CUBIC          FilterType = 10
+	FILTER_CATROM         FilterType = 11
+	FILTER_MITCHELL       FilterType = 12
+	FILTER_JINC           FilterType = 13
+	FILTER_SINC           FilterType = 14
+	FILTER_SINC_FAST      FilterType = 15
+	FILTER_KAISER         FilterType = 16
+	FILTER_WELSH          FilterType = 17
+	FILTER_PARZEN         FilterType = 18
+	FILTER_BOHMAN         FilterType = 19
+	FILTER_BARTLETT       FilterType = 20
+	FILTER_LAGRANGE       FilterType = 21
+	FILTER_LANCZOS        FilterType = 22
+	FILTER_LANCZOS_SHARP  FilterType = 23
+	FILTER_LANCZOS2       FilterType = 24
+	FILTER_LANCZOS2_SHARP FilterType = 25
+	FILTER_ROBIDOUX       FilterType = 26
+	FILTER_ROBIDOUX_SHARP FilterType = 27
+	FILTER_COSINE         FilterType = 28
+	FILTER_SPLINE         FilterType = 29
+	FILTER_SENTINEL       FilterType = 31 // Marks the end of the filters; it is not one and is rejected.
+	FILTER_LANCZOS_RADIUS FilterType = 30
 )
+
+// validate returns an error if the filter is not one of the filters above.
+func (f FilterType) validate() error {
+	if f < FILTER_UNDEFINED || f > FILTER_LANCZOS_RADIUS {
+		return fmt.Errorf("unknown filter type %d", f)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterType_validate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		filter        FilterType
+		expectedError error
+	}{
+		{name: "undefined", filter: FILTER_UNDEFINED},
+		{name: "lanczos", filter: FILTER_LANCZOS},
+		{name: "lanczos radius", filter: FILTER_LANCZOS_RADIUS},
+		{name: "sentinel", filter: FILTER_SENTINEL, expectedError: errors.New("unknown filter type 31")},
+		{name: "negative", filter: FilterType(-1), expectedError: errors.New("unknown filter type -1")},
+		{name: "out of range", filter: FilterType(99), expectedError: errors.New("unknown filter type 99")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.filter.validate()
+			if tc.expectedError != nil {
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestFilterType_values pins the values of the filters, which are part of the API.
+func TestFilterType_values(t *testing.T) {
+	require.Equal(t, FilterType(0), FILTER_UNDEFINED)
+	require.Equal(t, FilterType(22), FILTER_LANCZOS)
+	require.Equal(t, FilterType(29), FILTER_SPLINE)
+	require.Equal(t, FilterType(30), FILTER_LANCZOS_RADIUS)
+}
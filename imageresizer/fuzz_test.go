@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzMemory makes the fuzz tests decode images with the in-memory backend even when the
+// package is built with ImageMagick, which they fuzz otherwise.
+var fuzzMemory = flag.Bool("memory", false, "fuzz the in-memory backend instead of ImageMagick")
+
+// fuzzPolicy tightens UntrustedInputPolicy for fuzzing, bounding images to sizes that decode
+// quickly enough for fuzzing to make progress.
+const fuzzPolicy = `<policymap>
+  <policy domain="resource" name="width" value="1024"/>
+  <policy domain="resource" name="height" value="1024"/>
+  <policy domain="resource" name="area" value="1MP"/>
+  <policy domain="resource" name="list-length" value="64"/>
+</policymap>`
+
+// fuzzPolicyOnce applies the security policy of the fuzz tests once for the whole process.
+var fuzzPolicyOnce sync.Once
+
+// addSeeds adds the fixtures and the tricky files of testdata/seeds, along with their
+// extensions, to the seed corpus of f.
+func addSeeds(f *testing.F, add func(data []byte, extension string)) {
+	for _, pattern := range []string{"testdata/fixtures/*", "testdata/seeds/*"} {
+		paths, err := filepath.Glob(pattern)
+		require.NoError(f, err)
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			require.NoError(f, err)
+			add(data, filepath.Ext(path))
+		}
+	}
+}
+
+// newFuzzResizer creates a resizer with the given options for fuzzing, backed by ImageMagick
+// when the package is built with it and the -memory flag is not set, or in memory otherwise.
+// ImageMagick runs under UntrustedInputPolicy, tightened by fuzzPolicy, so that inputs
+// referencing other files, such as the SVG and MVG seeds, cannot read them.
+func newFuzzResizer(t *testing.T, options ...Option) *imageResizer {
+	if withImageMagick {
+		fuzzPolicyOnce.Do(func() {
+			require.NoError(t, SetSecurityPolicy(UntrustedInputPolicy))
+			require.NoError(t, SetSecurityPolicy(fuzzPolicy))
+		})
+	}
+	if *fuzzMemory || !withImageMagick {
+		options = append(options, WithMemoryBackend())
+	}
+	return New(options...).(*imageResizer)
+}
+
+func FuzzResize(f *testing.F) {
+	addSeeds(f, func(data []byte, extension string) { f.Add(data, extension) })
+	f.Fuzz(func(t *testing.T, data []byte, extension string) {
+		if strings.ContainsAny(extension, `/\`+"\x00") {
+			return // Uploads are stored under names of their own, so only the extension varies.
+		}
+		dir := t.TempDir()
+		input := filepath.Join(dir, "upload"+extension)
+		require.NoError(t, os.WriteFile(input, data, 0o644))
+		ir := newFuzzResizer(t, WithDimensions(8, 8), WithOutputDir(dir))
+		defer ir.Destroy()
+		output, err := ir.Resize(input)
+		if err != nil {
+			require.NotErrorIs(t, err, ErrPanic)
+			return
+		}
+		require.FileExists(t, output)
+		require.NotEqual(t, input, output)
+	})
+}
+
+func FuzzInspectReader(f *testing.F) {
+	addSeeds(f, func(data []byte, _ string) { f.Add(data) })
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ir := newFuzzResizer(t)
+		defer ir.Destroy()
+		info, err := ir.InspectReader(bytes.NewReader(data))
+		if err != nil {
+			require.NotErrorIs(t, err, ErrPanic)
+			return
+		}
+		require.Equal(t, int64(len(data)), info.Size)
+		require.GreaterOrEqual(t, info.Width, 0)
+		require.GreaterOrEqual(t, info.Height, 0)
+	})
+}
+
+func FuzzResizedImageFilePath(f *testing.F) {
+	f.Add("/path/to/someImage.jpg", "", "")
+	f.Add("someImage", "/output", "png")
+	f.Add("archive.tar.gz", "out/dir/", "WEBP")
+	f.Add("/path/to/.hidden", "", "")
+	f.Add("/path/to/image.", "", "")
+	f.Add("..", "", "")
+	f.Add("/", "", "jpg")
+	f.Add("", "", "")
+	f.Add("/path/to/someImage_resized.png", "/path/to", "")
+	f.Add("/path/with spaces/ünïcödé.jpeg", "", "avif")
+	f.Fuzz(func(t *testing.T, imageFilePath, outputDir, outputFormat string) {
+		if strings.ContainsAny(outputFormat, `/\`) {
+			return // Formats are names such as "png", set by the developer.
+		}
+		i := &imageResizer{outputDir: outputDir, outputFormat: outputFormat}
+		resizedImageFilePath := i.resizedImageFilePath(imageFilePath)
+		dir := outputDir
+		if dir == "" {
+			dir = filepath.Dir(imageFilePath)
+		}
+		require.Equal(t, filepath.Clean(dir), filepath.Dir(resizedImageFilePath), "output written outside of its directory")
+		require.NotEqual(t, filepath.Clean(imageFilePath), resizedImageFilePath, "output overwriting the input")
+		require.Contains(t, filepath.Base(resizedImageFilePath), "_resized")
+		if outputFormat != "" {
+			require.True(t, strings.HasSuffix(resizedImageFilePath, "."+strings.ToLower(outputFormat)))
+		}
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build cgo && !nomagick
+
+package imageresizer
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The golden-image suite resizes real fixture images with ImageMagick and compares the outputs
+// to the golden images in testdata/golden. It runs along with the other tests whenever the
+// package is built with ImageMagick, and is left out of builds without it, with cgo disabled
+// or the nomagick tag:
+//
+//	go test -run TestGolden ./imageresizer
+//
+// Run it with -update to regenerate the golden images after an intended change of the output,
+// then review them before committing. Until testdata/golden has been committed, the suite is
+// skipped rather than failed, since the golden images can only be generated with ImageMagick 7.
+var update = flag.Bool("update", false, "regenerate the golden images of TestGolden")
+
+// goldenTolerance is how far an output may drift from its golden image. Different versions of
+// ImageMagick and its delegates round pixels slightly differently, which must not fail the
+// suite, while a wrong filter, crop or color easily falls below these thresholds.
+var goldenTolerance = Tolerance{MinSSIM: 0.97, MinPSNR: 32}
+
+// goldenDir holds the golden images TestGolden compares the outputs to.
+var goldenDir = filepath.Join("testdata", "golden")
+
+func TestGolden(t *testing.T) {
+	if _, err := os.Stat(goldenDir); errors.Is(err, fs.ErrNotExist) && !*update {
+		t.Skipf("%s is absent; generate the golden images with -update (or the golden-update workflow) and commit them", goldenDir)
+	}
+	testCases := []struct {
+		name    string
+		fixture string
+		options []Option
+	}{
+		{name: "jpeg_point", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_POINT)}},
+		{name: "jpeg_box", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_BOX)}},
+		{name: "jpeg_triangle", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_TRIANGLE)}},
+		{name: "jpeg_catrom", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_CATROM)}},
+		{name: "jpeg_mitchell", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_MITCHELL)}},
+		{name: "jpeg_lanczos", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_LANCZOS)}},
+		{name: "jpeg_quality_50", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithCompressionQuality(50)}},
+		{name: "png_alpha_lanczos", fixture: "pattern.png", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_LANCZOS)}},
+		{name: "png_upscale_mitchell", fixture: "pattern.png", options: []Option{WithDimensions(192, 128), WithFilterType(FILTER_MITCHELL)}},
+		{name: "gif_point", fixture: "pattern.gif", options: []Option{WithDimensions(48, 32), WithFilterType(FILTER_POINT)}},
+		{name: "gif_to_png", fixture: "pattern.gif", options: []Option{WithDimensions(48, 32), WithOutputFormat("png")}},
+		{name: "jpeg_to_webp", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithOutputFormat("webp")}},
+		{name: "pad_color", fixture: "pattern.png", options: []Option{WithDimensions(48, 48), WithPad(Padding{Color: "#336699"})}},
+		{name: "pad_blur", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 48), WithPad(Padding{Blur: true})}},
+		{name: "sharpen", fixture: "pattern.jpg", options: []Option{WithDimensions(48, 32), WithSharpen(0, 1, 1.5, 0.02)}},
+		{name: "rotate_90", fixture: "pattern.png", options: []Option{WithDimensions(32, 48), WithRotate(90, "")}},
+		{name: "flip_flop", fixture: "pattern.png", options: []Option{WithDimensions(48, 32), WithFlip(), WithFlop()}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := New(append([]Option{WithOutputDir(t.TempDir())}, tc.options...)...)
+			defer ir.Destroy()
+			output, err := ir.Resize(filepath.Join("testdata", "fixtures", tc.fixture))
+			require.NoError(t, err)
+			golden := filepath.Join(goldenDir, tc.name+filepath.Ext(output))
+			if *update {
+				data, err := os.ReadFile(output)
+				require.NoError(t, err)
+				require.NoError(t, os.MkdirAll(goldenDir, 0o755))
+				require.NoError(t, os.WriteFile(golden, data, 0o644))
+				return
+			}
+			if _, err := os.Stat(golden); errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("golden image %s is missing; run the suite with -update to create it", golden)
+			}
+			comparison, err := ir.(Comparer).Compare(output, golden)
+			require.NoError(t, err)
+			require.NoError(t, comparison.Within(goldenTolerance), "%s drifted from its golden image: %+v", tc.name, comparison)
+		})
+	}
+}
+
+// textFontPath is the font text overlays are rendered with, Source Code Pro, which is bundled
+// under the SIL Open Font License so that the rendering does not depend on the fonts installed.
+var textFontPath = filepath.Join("testdata", "fonts", "SourceCodePro-Medium.ttf")
+
+func TestGoldenTextOverlay(t *testing.T) {
+	overlay := TextOverlay{
+		Text:            "© ACME",
+		FontPath:        textFontPath,
+		FontSize:        12,
+		Color:           "#ff00ff",
+		BackgroundColor: "#00ff00",
+		Gravity:         GRAVITY_SOUTH_EAST,
+		OffsetX:         4,
+		OffsetY:         4,
+	}
+	resize := func(t *testing.T, options ...Option) (image.Image, *imageResizer) {
+		ir := New(append([]Option{WithOutputDir(t.TempDir()), WithDimensions(96, 64), WithOutputFormat("png")}, options...)...).(*imageResizer)
+		t.Cleanup(ir.Destroy)
+		output, err := ir.Resize(filepath.Join("testdata", "fixtures", "pattern.png"))
+		require.NoError(t, err)
+		file, err := os.Open(output)
+		require.NoError(t, err)
+		defer file.Close()
+		img, err := png.Decode(file)
+		require.NoError(t, err)
+		return img, ir
+	}
+	plain, _ := resize(t)
+	stamped, ir := resize(t, WithText(overlay))
+	metrics, err := ir.mw.TextMetrics(overlay.style(96), overlay.Text)
+	require.NoError(t, err)
+	require.Greater(t, metrics.Width, 0.0)
+	x, y := gravityPosition(overlay.Gravity, 96, 64, int(metrics.Width+0.5), int(metrics.Height+0.5), overlay.OffsetX, overlay.OffsetY)
+	box := image.Rect(x, y, x+int(metrics.Width+0.5), y+int(metrics.Height+0.5))
+	// Antialiasing and the background box may spill over the measured box by a pixel.
+	margin := box.Inset(-2)
+	var inside, outside int
+	for py := 0; py < 64; py++ {
+		for px := 0; px < 96; px++ {
+			r1, g1, b1, a1 := plain.At(px, py).RGBA()
+			r2, g2, b2, a2 := stamped.At(px, py).RGBA()
+			if r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2 {
+				continue
+			}
+			if image.Pt(px, py).In(box) {
+				inside++
+			} else if !image.Pt(px, py).In(margin) {
+				outside++
+			}
+		}
+	}
+	require.Greater(t, inside, box.Dx()*box.Dy()/2, "text was not rendered in its gravity box %v", box)
+	require.Zero(t, outside, "text was rendered outside of its gravity box %v", box)
+}
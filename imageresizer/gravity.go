@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+// GravityType is the edge or corner of a canvas an object is attached to, as in ImageMagick.
+type GravityType int
+
+const (
+	GRAVITY_UNDEFINED GravityType = iota
+	GRAVITY_NORTH_WEST
+	GRAVITY_NORTH
+	GRAVITY_NORTH_EAST
+	GRAVITY_WEST
+	GRAVITY_CENTER
+	GRAVITY_EAST
+	GRAVITY_SOUTH_WEST
+	GRAVITY_SOUTH
+	GRAVITY_SOUTH_EAST
+)
+
+// gravityPosition returns the top left position of an object of size width x height placed on a
+// canvas of size canvasWidth x canvasHeight according to the given gravity. As in ImageMagick's
+// geometry, the offsets push the object away from the edge it is attached to, and are ignored
+// along the axis in which it is centered.
+func gravityPosition(gravity GravityType, canvasWidth, canvasHeight, width, height, offsetX, offsetY int) (x, y int) {
+	switch gravity {
+	case GRAVITY_NORTH, GRAVITY_CENTER, GRAVITY_SOUTH:
+		x = (canvasWidth - width) / 2
+	case GRAVITY_NORTH_EAST, GRAVITY_EAST, GRAVITY_SOUTH_EAST:
+		x = canvasWidth - width - offsetX
+	default:
+		x = offsetX
+	}
+	switch gravity {
+	case GRAVITY_WEST, GRAVITY_CENTER, GRAVITY_EAST:
+		y = (canvasHeight - height) / 2
+	case GRAVITY_SOUTH_WEST, GRAVITY_SOUTH, GRAVITY_SOUTH_EAST:
+		y = canvasHeight - height - offsetY
+	default:
+		y = offsetY
+	}
+	return x, y
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gravityPosition(t *testing.T) {
+	testCases := []struct {
+		gravity   GravityType
+		expectedX int
+		expectedY int
+	}{
+		{gravity: GRAVITY_UNDEFINED, expectedX: 10, expectedY: 20},
+		{gravity: GRAVITY_NORTH_WEST, expectedX: 10, expectedY: 20},
+		{gravity: GRAVITY_NORTH, expectedX: 200, expectedY: 20},
+		{gravity: GRAVITY_NORTH_EAST, expectedX: 390, expectedY: 20},
+		{gravity: GRAVITY_WEST, expectedX: 10, expectedY: 100},
+		{gravity: GRAVITY_CENTER, expectedX: 200, expectedY: 100},
+		{gravity: GRAVITY_EAST, expectedX: 390, expectedY: 100},
+		{gravity: GRAVITY_SOUTH_WEST, expectedX: 10, expectedY: 180},
+		{gravity: GRAVITY_SOUTH, expectedX: 200, expectedY: 180},
+		{gravity: GRAVITY_SOUTH_EAST, expectedX: 390, expectedY: 180},
+	}
+	for _, tc := range testCases {
+		x, y := gravityPosition(tc.gravity, 600, 300, 200, 100, 10, 20)
+		require.Equal(t, tc.expectedX, x, "gravity %d", tc.gravity)
+		require.Equal(t, tc.expectedY, y, "gravity %d", tc.gravity)
+	}
+}
@@ -6,12 +6,13 @@ package imageresizer
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
-	"gopkg.in/gographics/imagick.v3/imagick"
+	"github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
 )
 
 func init() {
@@ -22,6 +23,11 @@ func init() {
 	}
 }
 
+// ImageResizer resizes images. The ImageResizer returned by New also implements the other
+// interfaces of this package, such as ResultResizer, Inspector or PerceptualHasher, which give
+// access to further capabilities through a type assertion:
+//
+//	hash, err := ir.(imageresizer.PerceptualHasher).PerceptualHash("someImage.jpg")
 type ImageResizer interface {
 	// Resize resizes the image located at imageFilePath according to the settings of the imageResizer.
 	Resize(imageFilePath string) (string, error)
@@ -31,6 +37,86 @@ type ImageResizer interface {
 	Destroy()
 }
 
+// ResultResizer resizes images, reporting how they were resized.
+type ResultResizer interface {
+	// ResizeWithResult works like Resize, but also reports how the resized image was produced.
+	ResizeWithResult(imageFilePath string) (Result, error)
+}
+
+// PageResizer resizes the pages of multi-page images to separate files.
+type PageResizer interface {
+	// ResizePages resizes every selected page of the multi-page image (such as a TIFF or a PDF)
+	// located at imageFilePath, writing each one to its own file with the page number in its name.
+	ResizePages(imageFilePath string) ([]string, error)
+}
+
+// Inspector describes images without decoding their pixels.
+type Inspector interface {
+	// Inspect returns the format, dimensions and other attributes of the image located at
+	// imageFilePath, reading its header only.
+	Inspect(imageFilePath string) (ImageInfo, error)
+	// InspectReader works like Inspect, for an image provided by a reader.
+	InspectReader(r io.Reader) (ImageInfo, error)
+}
+
+// PlaceholderGenerator computes placeholders of images, to be shown while they load.
+type PlaceholderGenerator interface {
+	// Placeholder computes the placeholders selected by WithPlaceholder, or both a BlurHash and
+	// a ThumbHash if none was, for the image located at imageFilePath.
+	Placeholder(imageFilePath string) (Placeholder, error)
+}
+
+// ColorAnalyzer analyzes the colors of images.
+type ColorAnalyzer interface {
+	// Colors returns the average and dominant colors of the image located at imageFilePath, along
+	// with a palette of as many colors as set by WithColors, or 5 if none was.
+	Colors(imageFilePath string) (Colors, error)
+}
+
+// PerceptualHasher computes perceptual hashes of images, to find near-duplicates.
+type PerceptualHasher interface {
+	// PerceptualHash returns a 64-bit hash of the image located at imageFilePath, computed with
+	// the algorithm set by WithHashAlgorithm, which barely changes when the image is resized or
+	// re-encoded. Compare hashes with HammingDistance.
+	PerceptualHash(imageFilePath string) (uint64, error)
+}
+
+// Comparer compares images to reference images.
+type Comparer interface {
+	// Compare measures how much the image located at imageFilePath differs from the one located
+	// at referenceFilePath, which must have the same dimensions, as set by WithCompareOptions.
+	Compare(imageFilePath, referenceFilePath string) (Comparison, error)
+}
+
+// The resizers returned by New implement every interface of this package.
+var (
+	_ ImageResizer         = (*imageResizer)(nil)
+	_ ResultResizer        = (*imageResizer)(nil)
+	_ PageResizer          = (*imageResizer)(nil)
+	_ Inspector            = (*imageResizer)(nil)
+	_ PlaceholderGenerator = (*imageResizer)(nil)
+	_ ColorAnalyzer        = (*imageResizer)(nil)
+	_ PerceptualHasher     = (*imageResizer)(nil)
+	_ Comparer             = (*imageResizer)(nil)
+)
+
+// Result describes a resized image.
+type Result struct {
+	Path    string // Path of the resized image.
+	Quality int    // Compression quality the resized image was written with.
+
+	SSIM float64 // Structural similarity between the written and the unencoded resized image; set only when targeting an SSIM.
+
+	Candidates   []Candidate // Outputs in each candidate format, in the order the formats were given; set only when encoding to candidate formats.
+	ManifestPath string      // Path of the manifest describing the candidates; set only when all of them are kept.
+
+	InputBytes  int64 // Size of the input file, in bytes; set only in optimize-only mode.
+	OutputBytes int64 // Size of the output file, in bytes; set only in optimize-only mode.
+
+	Placeholder Placeholder // Placeholders of the resized image; set only when requested with WithPlaceholder.
+	Colors      Colors      // Colors of the resized image; set only when requested with WithColors.
+}
+
 // imageResizer encapsulates the settings and operations for resizing images.
 type imageResizer struct {
 	newWidth           *int       // Target width of the image; nil to keep original width.
@@ -38,17 +124,83 @@ type imageResizer struct {
 	compressionQuality int        // Compression quality of the resized image.
 	filterType         FilterType // Filter type used for the resizing process.
 	outputDir          string     // Directory where the resized image will be saved.
+	outputFormat       string     // Format of the resized image, e.g. "png"; empty to keep the original format.
 	mw                 magickWand // Wrapper around MagickWand, the ImageMagick API handler.
+	source             ImageInfo  // Attributes of the image being resized, pinged before it is read.
+
+	// Pre-processing applied to the original image, before the target dimensions are computed.
+	trimming *Trim     // Border trimming; nil to keep the borders.
+	flip     bool      // Whether to mirror the image vertically.
+	flop     bool      // Whether to mirror the image horizontally.
+	rotation *rotation // Rotation applied after mirroring; nil to keep the orientation.
+
+	// Handling of multi-frame images, such as animated GIFs.
+	frame       *int // Index of the single frame to keep as a still image; nil to keep all frames.
+	animateWebP bool // Whether to convert animated GIFs to animated WebP.
+
+	// Handling of multi-page documents, such as TIFFs and PDFs.
+	pages        *pageRange // Pages to read; nil to read all of them.
+	density      float64    // Resolution, in DPI, at which vector sources are rasterized; zero to match the dimensions.
+	contactStrip bool       // Whether to append all pages side by side into a single image.
+
+	transparentBackground bool // Whether vector sources are rasterized over a transparent background.
+
+	// Encoder settings, on top of the compression quality.
+	progressiveJPEG   bool              // Whether to write progressive JPEGs.
+	interlacedPNG     bool              // Whether to write interlaced PNGs.
+	chromaSubsampling ChromaSubsampling // Chroma subsampling of JPEG outputs.
+	optimizeCoding    bool              // Whether to compute optimal Huffman tables for JPEG outputs.
+	encoderOptions    EncoderOptions    // Settings applied only when writing their format.
+
+	// Target output size, for single-frame images.
+	maxOutputBytes int  // Maximum size of the output, in bytes; zero for no limit.
+	allowDownscale bool // Whether to reduce the dimensions when no quality fits the output in maxOutputBytes.
+
+	// Target perceptual quality, for single-frame images.
+	targetSSIM float64 // Minimum structural similarity between the output and the unencoded resized image; zero for none.
+
+	// Candidate formats, for single-frame images.
+	candidateFormats  []string // Formats the resized image is encoded to, keeping the smallest output.
+	keepAllCandidates bool     // Whether to keep the output in every candidate format, along with a manifest.
+
+	optimizeOnly bool // Whether to only shrink the file, without resizing unless dimensions are set.
+	exactDecode  bool // Whether to decode JPEGs at full size, even when a smaller size is enough for the dimensions.
+
+	placeholderOptions *PlaceholderOptions // Placeholders computed for the resized image; nil for none.
+	paletteSize        int                 // Number of palette colors reported for the resized image; zero for no color analysis.
+	hashAlgorithm      HashAlgorithm       // Algorithm perceptual hashes are computed with.
+	compareOptions     CompareOptions      // Settings of image comparisons.
+
+	memoryBackend bool // Whether images are processed in memory with Go's image packages instead of ImageMagick.
+
+	// Post-processing applied to the resized image, in this order.
+	padding       *Padding      // Letterboxing applied after resizing; nil to resize to the exact dimensions.
+	unsharpMask   *unsharpMask  // Unsharp mask applied after resizing; nil to skip sharpening.
+	autoSharpen   bool          // Whether to derive the unsharp mask from the reduction factor.
+	watermark     *Watermark    // Watermark composited after resizing; nil for no watermark.
+	watermarkBlob []byte        // Encoded watermark image, loaded on the first resize.
+	textOverlays  []TextOverlay // Text stamps rendered after resizing, in order.
+
+	newMagickWand func() magickWand // Creates auxiliary wands, such as the one holding the watermark.
 }
 
+// ErrNoImageMagick is returned when reading images with a resizer of a package built without
+// ImageMagick, with CGO_ENABLED=0 or the nomagick build tag, unless it was created with
+// WithMemoryBackend.
+var ErrNoImageMagick = errors.New("imageresizer was built without ImageMagick; use WithMemoryBackend to process images in memory")
+
 // New initializes a new imageResizer with provided options.
 func New(options ...Option) ImageResizer {
-	imagick.Initialize() // Initialize the ImageMagick environment.
 	resizer := new(imageResizer)
 	for _, option := range options {
 		option(resizer) // Apply each option to the resizer.
 	}
-	resizer.mw = &magickWandWrapper{imagick.NewMagickWand()}
+	if resizer.memoryBackend {
+		resizer.newMagickWand = wand.NewMemory
+	} else {
+		resizer.newMagickWand = defaultBackend() // Initialize the ImageMagick environment, if available.
+	}
+	resizer.mw = resizer.newMagickWand()
 	return resizer
 }
 
@@ -56,11 +208,10 @@ func New(options ...Option) ImageResizer {
 // It returns an error if the dimensions are not set correctly.
 func (ir *imageResizer) ensureDimensions() error {
 	if ir.newWidth == nil && ir.newHeight == nil {
-		// Default to the current dimensions if both width and height are not set.
-		currentWidth := int(ir.mw.GetImageWidth())
-		currentHeight := int(ir.mw.GetImageHeight())
-		ir.newWidth = IntPtr(currentWidth)
-		ir.newHeight = IntPtr(currentHeight)
+		// Default to the source dimensions if both width and height are not set.
+		sourceWidth, sourceHeight := ir.sourceDimensions()
+		ir.newWidth = IntPtr(sourceWidth)
+		ir.newHeight = IntPtr(sourceHeight)
 		return nil
 	}
 	if (ir.newWidth == nil) != (ir.newHeight == nil) {
@@ -72,54 +223,260 @@ func (ir *imageResizer) ensureDimensions() error {
 	return nil
 }
 
+// Resize resizes the image located at imageFilePath and writes it next to the original, or to
+// the output directory, returning the path of the resized image. It is ResizeWithResult
+// without the result details.
 func (i *imageResizer) Resize(imageFilePath string) (string, error) {
-	var resizedImageFilePath string
-	if err := i.mw.ReadImage(imageFilePath); err != nil {
-		return resizedImageFilePath, errors.Wrapf(err, "reading image %s", imageFilePath)
+	result, err := i.ResizeWithResult(imageFilePath)
+	if err != nil {
+		return "", err
 	}
-	if err := i.ensureDimensions(); err != nil {
-		return resizedImageFilePath, err
+	return result.Path, nil
+}
+
+// ResizeWithResult resizes the image located at imageFilePath, reporting the path of the
+// resized image along with how it was produced. Animations and multi-page documents are
+// resized frame by frame and written to a single output; the other images go through the
+// candidate formats, optimize-only or single-frame pipelines, depending on the settings.
+func (i *imageResizer) ResizeWithResult(imageFilePath string) (_ Result, err error) {
+	defer recoverPanic(&err)
+	if err := i.read(imageFilePath); err != nil {
+		return Result{}, err
+	}
+	frames := i.source.Frames
+	if i.frame != nil {
+		frames = 1 // Only the selected frame was kept.
+	}
+	if frames > 1 {
+		resize := i.resizeDocument
+		if isAnimationFormat(i.mw.GetImageFormat()) {
+			resize = i.resizeAnimation
+		}
+		resizedImageFilePath, err := resize(imageFilePath, frames)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Path: resizedImageFilePath, Quality: i.quality(i.outputFormatOf(resizedImageFilePath))}, nil
+	}
+	resizedImageFilePath := i.resizedImageFilePath(imageFilePath)
+	if len(i.candidateFormats) > 0 {
+		return i.resizeToSmallestFormat(resizedImageFilePath)
+	}
+	if i.optimizeOnly {
+		return i.optimize(imageFilePath, resizedImageFilePath)
+	}
+	format := i.outputFormatOf(resizedImageFilePath)
+	if err := i.resizeFrame(format); err != nil {
+		return Result{}, err
 	}
-	if err := i.mw.ResizeImage(uint(*i.newWidth), uint(*i.newHeight), imagick.FilterType(i.filterType)); err != nil {
-		return resizedImageFilePath, errors.Wrap(err, "resizing image")
+	result, err := i.tuneQuality(format)
+	if err != nil {
+		return Result{}, err
 	}
-	if err := i.mw.SetImageCompressionQuality(uint(i.compressionQuality)); err != nil {
-		return resizedImageFilePath, errors.Wrapf(err, "setting image compression quality to %d", i.compressionQuality)
+	if result.Placeholder, err = i.resizedPlaceholder(); err != nil {
+		return Result{}, err
+	}
+	if result.Colors, err = i.resizedColors(); err != nil {
+		return Result{}, err
 	}
-	resizedImageFilePath = i.resizedImageFilePath(imageFilePath)
 	if err := i.mw.WriteImage(resizedImageFilePath); err != nil {
-		return "", errors.Wrapf(err, "writing image %s", resizedImageFilePath)
+		return Result{}, errors.Wrapf(err, "writing image %s", resizedImageFilePath)
+	}
+	result.Path = resizedImageFilePath
+	return result, nil
+}
+
+// tuneQuality sets the compression quality of the resized image to the one matching the target
+// SSIM and fitting the maximum output size, if any, reporting it along with the SSIM achieved.
+func (i *imageResizer) tuneQuality(format string) (Result, error) {
+	result := Result{Quality: i.quality(format)}
+	if i.targetSSIM > 0 {
+		quality, ssim, err := i.searchSSIMQuality(format, result.Quality)
+		if err != nil {
+			return Result{}, errors.Wrapf(err, "targeting SSIM %g", i.targetSSIM)
+		}
+		if err := i.mw.SetImageCompressionQuality(uint(quality)); err != nil {
+			return Result{}, errors.Wrapf(err, "setting image compression quality to %d", quality)
+		}
+		result.Quality, result.SSIM = quality, ssim
+	}
+	if i.maxOutputBytes > 0 {
+		quality, err := i.fitOutputSize(format, result.Quality)
+		if err != nil {
+			return Result{}, errors.Wrapf(err, "fitting output in %d bytes", i.maxOutputBytes)
+		}
+		if i.targetSSIM > 0 && quality != result.Quality {
+			// The size limit took precedence over the target SSIM, so the achieved one is lower.
+			if result.SSIM, err = i.ssimAt(quality); err != nil {
+				return Result{}, errors.Wrapf(err, "measuring SSIM at quality %d", quality)
+			}
+		}
+		result.Quality = quality
+	}
+	return result, nil
+}
+
+// read pings the image located at imageFilePath, keeping its attributes to drive the resize,
+// then loads it into the wand, replacing the images of any previous resize, and keeps only the
+// selected pages or frame.
+func (i *imageResizer) read(imageFilePath string) error {
+	if err := i.pages.validate(); err != nil {
+		return err
+	}
+	if err := i.filterType.validate(); err != nil {
+		return err
+	}
+	i.mw.Clear() // Drop the images of any previous resize.
+	info, err := i.ping(imageFilePath + i.pages.selector())
+	if err != nil {
+		return err
+	}
+	i.source = info
+	if density := i.readDensity(info); density > 0 {
+		// The resolution must be set before reading, as it drives the rasterization of vector sources.
+		if err := i.mw.SetResolution(density, density); err != nil {
+			return errors.Wrapf(err, "setting density to %g", density)
+		}
+	}
+	if size := i.jpegSizeHint(info); size != "" {
+		if err := i.mw.SetOption("jpeg:size", size); err != nil {
+			return errors.Wrapf(err, "setting jpeg:size to %s", size)
+		}
+	}
+	if i.transparentBackground {
+		if err := i.mw.SetBackground("none"); err != nil {
+			return errors.Wrap(err, "setting transparent background")
+		}
+	}
+	if err := i.mw.ReadImage(imageFilePath + i.pages.selector()); err != nil {
+		return errors.Wrapf(err, "reading image %s", imageFilePath)
+	}
+	if i.frame != nil {
+		if err := i.mw.SelectFrame(*i.frame); err != nil {
+			return errors.Wrapf(err, "selecting frame %d", *i.frame)
+		}
+	}
+	return nil
+}
+
+// firstFrameSelector is the suffix that makes ImageMagick read only the first frame, or page,
+// of an image, without decoding the other ones.
+const firstFrameSelector = "[0]"
+
+// readSmall reads the first frame of the image located at imageFilePath into a new wand, which
+// the caller must destroy. Only a small copy of the image is needed, so JPEGs are scaled down
+// while decoded, as much as possible without getting smaller than maxSide x maxSide.
+func (i *imageResizer) readSmall(imageFilePath string, maxSide int) (magickWand, error) {
+	mw := i.newMagickWand()
+	size := fmt.Sprintf("%dx%d", maxSide, maxSide)
+	if err := mw.SetOption("jpeg:size", size); err != nil {
+		mw.Destroy()
+		return nil, errors.Wrapf(err, "setting jpeg:size to %s", size)
+	}
+	if err := mw.ReadImage(imageFilePath + firstFrameSelector); err != nil {
+		mw.Destroy()
+		return nil, errors.Wrapf(err, "reading image %s", imageFilePath)
+	}
+	mw.SetIteratorIndex(0)
+	return mw, nil
+}
+
+// resizeFrame applies the whole pipeline to the current image of the wand: pre-processing,
+// resizing, post-processing and the settings of the encoder of the given output format.
+func (i *imageResizer) resizeFrame(format string) error {
+	if err := i.transformFrame(); err != nil {
+		return err
+	}
+	quality := i.quality(format)
+	if err := i.mw.SetImageCompressionQuality(uint(quality)); err != nil {
+		return errors.Wrapf(err, "setting image compression quality to %d", quality)
+	}
+	return i.setEncoderOptions(format)
+}
+
+// transformFrame applies the pre-processing, resizing and post-processing steps of the pipeline
+// to the current image of the wand. Unlike resizeFrame, it leaves the encoder settings alone, so
+// that the image can still be encoded to any format.
+func (i *imageResizer) transformFrame() error {
+	if err := i.trim(); err != nil {
+		return errors.Wrap(err, "trimming image")
+	}
+	if err := i.orient(); err != nil {
+		return err
+	}
+	originalWidth, originalHeight := i.sourceDimensions()
+	keepSize := i.optimizeOnly && i.newWidth == nil && i.newHeight == nil
+	// When not set, the dimensions default to those of the current image, so they must
+	// not carry over to the next page or the next resize.
+	defer func(width, height *int) { i.newWidth, i.newHeight = width, height }(i.newWidth, i.newHeight)
+	if err := i.ensureDimensions(); err != nil {
+		return err
+	}
+	width, height := *i.newWidth, *i.newHeight
+	if i.padding != nil {
+		width, height = fitDimensions(originalWidth, originalHeight, width, height)
+	}
+	if !keepSize {
+		if err := i.mw.ResizeImage(uint(width), uint(height), i.filterType.wandFilter()); err != nil {
+			return errors.Wrap(err, "resizing image")
+		}
 	}
-	return resizedImageFilePath, nil
+	if err := i.sharpen(originalWidth, originalHeight); err != nil {
+		return errors.Wrap(err, "sharpening image")
+	}
+	if err := i.pad(); err != nil {
+		return errors.Wrap(err, "padding image")
+	}
+	if err := i.applyWatermark(); err != nil {
+		return errors.Wrap(err, "applying watermark")
+	}
+	if err := i.applyTextOverlays(); err != nil {
+		return errors.Wrap(err, "applying text overlay")
+	}
+	return nil
 }
 
+// sourceDimensions returns the dimensions of the image being resized, as displayed once
+// oriented. They come from its pinged attributes, which unlike the decoded image are not
+// reduced by the jpeg:size hint, with width and height swapped when its EXIF orientation
+// transposes it. The current image is measured instead when trimming or rotating changed its
+// dimensions, or when the source has several frames or pages, which may differ in size.
+func (i *imageResizer) sourceDimensions() (int, int) {
+	if i.source.Width <= 0 || i.source.Height <= 0 || i.source.Frames != 1 || i.trimming != nil || i.rotation != nil {
+		return int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+	}
+	return i.source.orientedDimensions()
+}
+
+// Destroy releases the wand holding the images read by the resizer, which must not be used
+// afterwards.
 func (i *imageResizer) Destroy() {
 	i.mw.Destroy()
 }
 
-// Terminate releases resources used by imageResizer and ImageMagick. It is the responsibility
-// of the caller to invoke this function after completing image resizing operations. Failing to
-// call Terminate can lead to resource leaks as it cleans up the MagickWand instance and
-// terminates the ImageMagick environment. This is crucial especially in long-running
-// applications or those processing large numbers of images, to avoid excessive memory usage.
-func Terminate() {
-	imagick.Terminate()
+// resizedImageFilePath returns the path the resized image is written to, in the output directory
+// and format of the resizer.
+func (i *imageResizer) resizedImageFilePath(imageFilePath string) string {
+	return ResizedImageFilePath(imageFilePath, i.outputDir, i.outputFormat)
 }
 
-// resizedImageFilePath generates the file path for the resized image. It uses the output directory
-// specified in the imageResizer. If no output directory is specified, the original image file path
-// is used as the base path. This ensures that the resized image is saved either in a specified
-// location or alongside the original image if no specific output location is provided.
-func (i *imageResizer) resizedImageFilePath(imageFilePath string) string {
+// ResizedImageFilePath generates the file path for the resized image. It uses the given output
+// directory; if it is empty, the directory of the original image file path is used as the base
+// path. This ensures that the resized image is saved either in a specified location or alongside
+// the original image if no specific output location is provided. The extension of the original
+// image is kept, unless an output format is given.
+func ResizedImageFilePath(imageFilePath, outputDir, outputFormat string) string {
 	basePath := filepath.Dir(imageFilePath)
-	if i.outputDir != "" {
-		basePath = i.outputDir
+	if outputDir != "" {
+		basePath = outputDir
 	}
 	fileName := filepath.Base(imageFilePath)
-	dotIndex := strings.LastIndex(fileName, ".")
-	if dotIndex == -1 {
-		return filepath.Join(basePath, fileName+"_resized")
+	extension := ""
+	if dotIndex := strings.LastIndex(fileName, "."); dotIndex != -1 {
+		fileName, extension = fileName[:dotIndex], fileName[dotIndex:]
+	}
+	if outputFormat != "" {
+		extension = "." + strings.ToLower(outputFormat)
 	}
-	newFileName := fileName[:dotIndex] + "_resized" + fileName[dotIndex:]
-	return filepath.Join(basePath, newFileName)
+	return filepath.Join(basePath, fileName+"_resized"+extension)
 }
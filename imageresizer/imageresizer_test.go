@@ -6,11 +6,13 @@ package imageresizer
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gopkg.in/gographics/imagick.v3/imagick"
+	"github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
 )
 
 func TestNew(t *testing.T) {
@@ -22,6 +24,9 @@ func TestNew(t *testing.T) {
 		expectedCompressionQuality int
 		expectedOutputDir          string
 		expectedFilterType         FilterType
+		expectedUnsharpMask        *unsharpMask
+		expectedWatermark          *Watermark
+		expectedTextOverlays       []TextOverlay
 	}{
 		{
 			name: "with all options",
@@ -30,12 +35,18 @@ func TestNew(t *testing.T) {
 				WithCompressionQuality(50),
 				WithFilterType(FILTER_LANCZOS),
 				WithOutputDir("path/to/some/dir"),
+				WithSharpen(0, 0.8, 1.2, 0.02),
+				WithWatermark(Watermark{Path: "path/to/logo.png", Opacity: 0.5}),
+				WithText(TextOverlay{Text: "© ACME 2026"}),
 			},
 			expectedNewWidth:           IntPtr(800),
 			expectedNewHeight:          IntPtr(600),
 			expectedCompressionQuality: 50,
 			expectedOutputDir:          "path/to/some/dir",
 			expectedFilterType:         FILTER_LANCZOS,
+			expectedUnsharpMask:        &unsharpMask{sigma: 0.8, amount: 1.2, threshold: 0.02},
+			expectedWatermark:          &Watermark{Path: "path/to/logo.png", Opacity: 0.5},
+			expectedTextOverlays:       []TextOverlay{{Text: "© ACME 2026"}},
 		},
 		{
 			name: "no options",
@@ -52,6 +63,9 @@ func TestNew(t *testing.T) {
 			assert.Equal(t, tc.expectedCompressionQuality, ir.compressionQuality)
 			assert.Equal(t, tc.expectedOutputDir, ir.outputDir)
 			assert.Equal(t, tc.expectedFilterType, ir.filterType)
+			assert.Equal(t, tc.expectedUnsharpMask, ir.unsharpMask)
+			assert.Equal(t, tc.expectedWatermark, ir.watermark)
+			assert.Equal(t, tc.expectedTextOverlays, ir.textOverlays)
 			imgResizer.Destroy()
 		})
 	}
@@ -60,7 +74,18 @@ func TestNew(t *testing.T) {
 func TestResize(t *testing.T) {
 	testCases := []struct {
 		name           string
+		frame          *int
 		newWidth       *int
+		newHeight      *int
+		trimming       *Trim
+		rotation       *rotation
+		padding        *Padding
+		unsharpMask    *unsharpMask
+		watermark      *Watermark
+		textOverlays   []TextOverlay
+		maxOutputBytes int
+		filterType     FilterType
+		outputFormat   string
 		mockClosure    func(m *mockMagickWand)
 		expectedOutput string
 		expectedError  error
@@ -70,6 +95,12 @@ func TestResize(t *testing.T) {
 			mockClosure:    func(m *mockMagickWand) {},
 			expectedOutput: "/path/to/dir/someImage_resized.jpg",
 		},
+		{
+			name:          "unknown filter type",
+			filterType:    FILTER_SENTINEL,
+			mockClosure:   func(m *mockMagickWand) {},
+			expectedError: errors.New("unknown filter type 31"),
+		},
 		{
 			name: "error when reading image",
 			mockClosure: func(m *mockMagickWand) {
@@ -77,6 +108,75 @@ func TestResize(t *testing.T) {
 			},
 			expectedError: errors.New("reading image someImage.jpg: read image error"),
 		},
+		{
+			name: "error when selecting frame",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSelectFrame = errors.New("select frame error")
+			},
+			frame:         IntPtr(2),
+			expectedError: errors.New("selecting frame 2: select frame error"),
+		},
+		{
+			name:      "error when setting jpeg size hint",
+			newWidth:  IntPtr(400),
+			newHeight: IntPtr(300),
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "JPEG"
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting jpeg:size to 400x300: set option error"),
+		},
+		{
+			name: "animation",
+			mockClosure: func(m *mockMagickWand) {
+				m.numberImages = 3
+				m.format = "GIF"
+			},
+			expectedOutput: "/path/to/dir/someImage_resized.jpg",
+		},
+		{
+			name: "error when resizing a frame of an animation",
+			mockClosure: func(m *mockMagickWand) {
+				m.numberImages = 3
+				m.format = "GIF"
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("frame 0: resizing image: resize image error"),
+		},
+		{
+			name:         "multi-page document",
+			outputFormat: "tiff",
+			mockClosure: func(m *mockMagickWand) {
+				m.numberImages = 3
+				m.format = "TIFF"
+			},
+			expectedOutput: "/path/to/dir/someImage_resized.tiff",
+		},
+		{
+			name:         "multi-page document to png",
+			outputFormat: "png",
+			mockClosure: func(m *mockMagickWand) {
+				m.numberImages = 3
+				m.format = "TIFF"
+			},
+			expectedError: errors.New("PNG cannot hold the 3 pages of someImage.jpg; use ResizePages, WithContactStrip or a multi-page output format such as TIFF or PDF"),
+		},
+		{
+			name: "error when trimming image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errTrimBorders = errors.New("trim borders error")
+			},
+			trimming:      &Trim{Fuzz: 0.1},
+			expectedError: errors.New("trimming image: trim borders error"),
+		},
+		{
+			name: "error when rotating image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errRotate = errors.New("rotate error")
+			},
+			rotation:      &rotation{degrees: 90},
+			expectedError: errors.New("rotating image by 90 degrees: rotate error"),
+		},
 		{
 			name:          "error when ensuring dimensions",
 			mockClosure:   func(m *mockMagickWand) {},
@@ -90,6 +190,40 @@ func TestResize(t *testing.T) {
 			},
 			expectedError: errors.New("resizing image: resize image error"),
 		},
+		{
+			name: "error when sharpening",
+			mockClosure: func(m *mockMagickWand) {
+				m.errUnsharpMaskImage = errors.New("unsharp mask image error")
+			},
+			unsharpMask:   &unsharpMask{sigma: 0.8, amount: 1.2, threshold: 0.02},
+			expectedError: errors.New("sharpening image: unsharp mask image error"),
+		},
+		{
+			name: "error when padding",
+			mockClosure: func(m *mockMagickWand) {
+				m.errExtend = errors.New("extend error")
+			},
+			newWidth:      IntPtr(1000),
+			newHeight:     IntPtr(1000),
+			padding:       &Padding{},
+			expectedError: errors.New("padding image: extend error"),
+		},
+		{
+			name: "error when applying watermark",
+			mockClosure: func(m *mockMagickWand) {
+				m.errComposite = errors.New("composite error")
+			},
+			watermark:     &Watermark{Reader: strings.NewReader("watermark")},
+			expectedError: errors.New("applying watermark: compositing watermark: composite error"),
+		},
+		{
+			name: "error when applying text overlay",
+			mockClosure: func(m *mockMagickWand) {
+				m.errAnnotate = errors.New("annotate error")
+			},
+			textOverlays:  []TextOverlay{{Text: "© ACME 2026"}},
+			expectedError: errors.New(`applying text overlay: drawing text "© ACME 2026": annotate error`),
+		},
 		{
 			name: "error when setting image compression quality",
 			mockClosure: func(m *mockMagickWand) {
@@ -97,6 +231,14 @@ func TestResize(t *testing.T) {
 			},
 			expectedError: errors.New("setting image compression quality to 50: set image compression quality error"),
 		},
+		{
+			name: "error when fitting output size",
+			mockClosure: func(m *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			maxOutputBytes: 10000,
+			expectedError:  errors.New("fitting output in 10000 bytes: encoding image at quality 25: get image blob error"),
+		},
 		{
 			name: "error when writing the resized image",
 			mockClosure: func(m *mockMagickWand) {
@@ -111,9 +253,21 @@ func TestResize(t *testing.T) {
 			tc.mockClosure(m)
 			ir := &imageResizer{
 				mw:                 m,
+				frame:              tc.frame,
 				newWidth:           tc.newWidth,
+				newHeight:          tc.newHeight,
+				trimming:           tc.trimming,
+				rotation:           tc.rotation,
+				padding:            tc.padding,
+				unsharpMask:        tc.unsharpMask,
+				watermark:          tc.watermark,
+				textOverlays:       tc.textOverlays,
+				maxOutputBytes:     tc.maxOutputBytes,
+				filterType:         tc.filterType,
+				outputFormat:       tc.outputFormat,
 				compressionQuality: 50,
 				outputDir:          "/path/to/dir",
+				newMagickWand:      func() magickWand { return new(mockMagickWand) },
 			}
 			output, err := ir.Resize("someImage.jpg")
 			if err != nil {
@@ -131,11 +285,95 @@ func TestResize(t *testing.T) {
 	}
 }
 
+func TestResizeWithResult(t *testing.T) {
+	testCases := []struct {
+		name           string
+		maxOutputBytes int
+		targetSSIM     float64
+		paletteSize    int
+		expectedResult Result
+		expectedError  error
+	}{
+		{
+			name:           "compression quality",
+			expectedResult: Result{Path: "/path/to/dir/someImage_resized.jpg", Quality: 80},
+		},
+		{
+			name:           "max output bytes",
+			maxOutputBytes: 30500,
+			expectedResult: Result{Path: "/path/to/dir/someImage_resized.jpg", Quality: 30},
+		},
+		{
+			name:           "target ssim",
+			targetSSIM:     0.949,
+			expectedResult: Result{Path: "/path/to/dir/someImage_resized.jpg", Quality: 75, SSIM: 0.95},
+		},
+		{
+			name:           "max output bytes takes precedence over target ssim",
+			maxOutputBytes: 30500,
+			targetSSIM:     0.949,
+			expectedResult: Result{Path: "/path/to/dir/someImage_resized.jpg", Quality: 30, SSIM: 0.86},
+		},
+		{
+			name:        "colors",
+			paletteSize: 3,
+			expectedResult: Result{
+				Path:    "/path/to/dir/someImage_resized.jpg",
+				Quality: 80,
+				Colors:  Colors{Average: "#1e90ff", Dominant: "#1e90ff", Palette: []Swatch{{Color: "#1e90ff", Share: 100}}},
+			},
+		},
+		{
+			name:          "error when targeting ssim",
+			targetSSIM:    1.1,
+			expectedError: errors.New("targeting SSIM 1.1: decoding image encoded at quality 40: read image blob error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{rgba: [4]byte{30, 144, 255, 255}}
+			ir := &imageResizer{
+				mw:                 m,
+				compressionQuality: 80,
+				maxOutputBytes:     tc.maxOutputBytes,
+				targetSSIM:         tc.targetSSIM,
+				paletteSize:        tc.paletteSize,
+				outputDir:          "/path/to/dir",
+				newMagickWand: func() magickWand {
+					encoded := &mockMagickWand{dssim: func() float64 { return float64(100-m.quality) / 1000 }}
+					if tc.targetSSIM > 1 {
+						encoded.errReadImageBlob = errors.New("read image blob error")
+					}
+					return encoded
+				},
+			}
+			result, err := ir.ResizeWithResult("someImage.jpg")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedResult.Path, result.Path)
+				require.Equal(t, tc.expectedResult.Quality, result.Quality)
+				require.InDelta(t, tc.expectedResult.SSIM, result.SSIM, 1e-9)
+				require.Equal(t, uint(tc.expectedResult.Quality), m.quality)
+				require.Equal(t, []string{tc.expectedResult.Path}, m.writtenImage)
+			}
+		})
+	}
+}
+
 func Test_ensureDimensions(t *testing.T) {
 	testCases := []struct {
 		name              string
 		newWidth          *int
 		newHeight         *int
+		source            ImageInfo
+		trimming          *Trim
 		expectedNewWidth  *int
 		expectedNewHeight *int
 		expectedError     error
@@ -145,6 +383,25 @@ func Test_ensureDimensions(t *testing.T) {
 			expectedNewWidth:  IntPtr(1200),
 			expectedNewHeight: IntPtr(850),
 		},
+		{
+			name:              "no dimensions provided, source transposed by its orientation",
+			source:            ImageInfo{Width: 4000, Height: 3000, Frames: 1, Orientation: 6},
+			expectedNewWidth:  IntPtr(3000),
+			expectedNewHeight: IntPtr(4000),
+		},
+		{
+			name:              "no dimensions provided, trimmed source",
+			source:            ImageInfo{Width: 4000, Height: 3000, Frames: 1},
+			trimming:          &Trim{},
+			expectedNewWidth:  IntPtr(1200),
+			expectedNewHeight: IntPtr(850),
+		},
+		{
+			name:              "no dimensions provided, source with several frames",
+			source:            ImageInfo{Width: 4000, Height: 3000, Frames: 3},
+			expectedNewWidth:  IntPtr(1200),
+			expectedNewHeight: IntPtr(850),
+		},
 		{
 			name:          "only width was provided",
 			newHeight:     IntPtr(850),
@@ -175,6 +432,8 @@ func Test_ensureDimensions(t *testing.T) {
 				newWidth:  tc.newWidth,
 				newHeight: tc.newHeight,
 				mw:        m,
+				source:    tc.source,
+				trimming:  tc.trimming,
 			}
 			err := ir.ensureDimensions()
 			if err != nil {
@@ -198,6 +457,7 @@ func Test_resizedImageFilePath(t *testing.T) {
 		name           string
 		input          string
 		outputDir      string
+		outputFormat   string
 		expectedOutput string
 	}{
 		{
@@ -222,11 +482,24 @@ func Test_resizedImageFilePath(t *testing.T) {
 			outputDir:      "newpath/to/some",
 			expectedOutput: "newpath/to/some/file_resized",
 		},
+		{
+			name:           "with output format, with extension",
+			input:          "path/to/some/file.pdf",
+			outputFormat:   "png",
+			expectedOutput: "path/to/some/file_resized.png",
+		},
+		{
+			name:           "with output format, without extension",
+			input:          "path/to/some/file",
+			outputFormat:   "JPG",
+			expectedOutput: "path/to/some/file_resized.jpg",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ir := &imageResizer{
-				outputDir: tc.outputDir,
+				outputDir:    tc.outputDir,
+				outputFormat: tc.outputFormat,
 			}
 			output := ir.resizedImageFilePath(tc.input)
 			require.Equal(t, tc.expectedOutput, output)
@@ -234,35 +507,388 @@ func Test_resizedImageFilePath(t *testing.T) {
 	}
 }
 
+type extent struct {
+	width, height uint
+	x, y          int
+	background    string
+}
+
+type annotation struct {
+	style textStyle
+	x, y  float64
+	text  string
+}
+
 type mockMagickWand struct {
+	numberImages                  uint
+	format                        string
+	errSelectFrame                error
+	errCoalesce                   error
+	errOptimizeLayers             error
+	errWriteImages                error
+	writtenImages                 string
+	errSetResolution              error
+	errPingImage                  error
+	errSetBackground              error
+	pingFormat                    string
+	resolution                    float64
+	densities                     []float64
+	errAppendAll                  error
+	readImage                     string
+	writtenImage                  []string
 	errReadImage                  error
+	errReadImageBlob              error
 	errResizeImage                error
+	errUnsharpMaskImage           error
+	errSetImageAlphaChannel       error
+	errEvaluateImage              error
+	errComposite                  error
+	errTrimBorders                error
+	errAutoOrientImage            error
+	errFlipImage                  error
+	errFlopImage                  error
+	errRotate                     error
+	operations                    []string
+	errCropImage                  error
+	errResetImagePage             error
+	errExtend                     error
+	errGaussianBlurImage          error
+	extents                       []extent
+	errTextMetrics                error
+	errAnnotate                   error
+	annotations                   []annotation
 	errSetImageCompressionQuality error
+	errSetInterlaceScheme         error
+	interlaceScheme               interlaceType
+	errSetOption                  error
+	errQuantize                   error
+	options                       map[string]string
 	errWriteImage                 error
+
+	width, height     uint                                  // Current dimensions, updated by ResizeImage; zero reports 1200x850.
+	quality           uint                                  // Last compression quality set.
+	blobSize          func(quality, width, height uint) int // Size of the encoded image; defaults to 1000 bytes per quality point.
+	errGetImageBlob   error
+	errSetImageFormat error
+	imageFormat       string // Last image format set.
+
+	dssim         func() float64 // Distortion reported against any reference; zero when nil.
+	errDistortion error
+
+	colors          uint // Unique colors of the image.
+	inputQuality    uint // Compression quality of the image, as read.
+	quantizedColors uint // Colors the image was last quantized to.
+	resizes         int  // Number of ResizeImage calls.
+	errStripImage   error
+
+	orientation int
+
+	rgba          [4]byte // Color of every pixel exported.
+	errExportRGBA error
+
+	histogram []histogramColor // Colors reported by Histogram; nil reports every pixel with the exported color.
+
+	distortions  map[metricType]float64 // Distortion reported for each metric, instead of dssim.
+	fuzz         float64                // Fuzz last set, as a percentage.
+	errSetFuzz   error
+	diff         *mockMagickWand // Wand returned by DiffImage.
+	errDiffImage error
+
+	clone       *mockMagickWand // Wand returned by CloneWand; nil returns the wand itself.
+	imageClones int             // Number of CloneImage calls.
 }
 
 func (m *mockMagickWand) ReadImage(filename string) error {
+	m.readImage = filename
 	return m.errReadImage
 }
 
-func (m *mockMagickWand) ResizeImage(cols uint, rows uint, filter imagick.FilterType) error {
+func (m *mockMagickWand) ReadImageBlob(blob []byte) error {
+	return m.errReadImageBlob
+}
+
+func (m *mockMagickWand) ResizeImage(cols uint, rows uint, filter wand.Filter) error {
+	m.resizes++
+	if m.width > 0 && m.errResizeImage == nil {
+		m.width, m.height = cols, rows
+	}
 	return m.errResizeImage
 }
 
+func (m *mockMagickWand) UnsharpMaskImage(radius, sigma, amount, threshold float64) error {
+	return m.errUnsharpMaskImage
+}
+
 func (m *mockMagickWand) GetImageWidth() uint {
+	if m.width > 0 {
+		return m.width
+	}
 	return uint(1200)
 }
 
 func (m *mockMagickWand) GetImageHeight() uint {
+	if m.height > 0 {
+		return m.height
+	}
 	return uint(850)
 }
 
+func (m *mockMagickWand) GetImageAlphaChannel() bool {
+	return false
+}
+
+func (m *mockMagickWand) SetImageAlphaChannel(operation alphaChannelType) error {
+	return m.errSetImageAlphaChannel
+}
+
+func (m *mockMagickWand) SetImageChannelMask(channel channelType) channelType {
+	return channelsDefault
+}
+
+func (m *mockMagickWand) EvaluateImage(op evaluateOperator, value float64) error {
+	return m.errEvaluateImage
+}
+
+func (m *mockMagickWand) Composite(source magickWand, compose compositeOperator, x, y int) error {
+	return m.errComposite
+}
+
+func (m *mockMagickWand) TrimBorders(color string, fuzz float64) (string, error) {
+	if color == "" {
+		color = "srgb(255,255,255)"
+	}
+	return color, m.errTrimBorders
+}
+
+func (m *mockMagickWand) AutoOrientImage() error {
+	m.operations = append(m.operations, "auto-orient")
+	return m.errAutoOrientImage
+}
+
+func (m *mockMagickWand) FlipImage() error {
+	m.operations = append(m.operations, "flip")
+	return m.errFlipImage
+}
+
+func (m *mockMagickWand) FlopImage() error {
+	m.operations = append(m.operations, "flop")
+	return m.errFlopImage
+}
+
+func (m *mockMagickWand) Rotate(degrees float64, background string) error {
+	m.operations = append(m.operations, fmt.Sprintf("rotate %g %s", degrees, background))
+	return m.errRotate
+}
+
+func (m *mockMagickWand) CloneWand() magickWand {
+	if m.clone != nil {
+		return m.clone
+	}
+	return m
+}
+
+func (m *mockMagickWand) CloneImage() magickWand {
+	m.imageClones++
+	return m
+}
+
+func (m *mockMagickWand) CropImage(width, height uint, x, y int) error {
+	return m.errCropImage
+}
+
+func (m *mockMagickWand) ResetImagePage(page string) error {
+	return m.errResetImagePage
+}
+
+func (m *mockMagickWand) Extend(width, height uint, x, y int, background string) error {
+	m.extents = append(m.extents, extent{width, height, x, y, background})
+	return m.errExtend
+}
+
+func (m *mockMagickWand) GaussianBlurImage(radius, sigma float64) error {
+	return m.errGaussianBlurImage
+}
+
+func (m *mockMagickWand) TextMetrics(style textStyle, text string) (textMetrics, error) {
+	return textMetrics{Width: 100, Height: 20, Ascender: 15}, m.errTextMetrics
+}
+
+func (m *mockMagickWand) Annotate(style textStyle, x, y float64, text string) error {
+	m.annotations = append(m.annotations, annotation{style, x, y, text})
+	return m.errAnnotate
+}
+
 func (m *mockMagickWand) SetImageCompressionQuality(quality uint) error {
+	m.quality = quality
 	return m.errSetImageCompressionQuality
 }
 
+func (m *mockMagickWand) SetImageFormat(format string) error {
+	m.imageFormat = format
+	return m.errSetImageFormat
+}
+
+func (m *mockMagickWand) GetImageBlob() ([]byte, error) {
+	if m.errGetImageBlob != nil {
+		return nil, m.errGetImageBlob
+	}
+	if m.blobSize == nil {
+		return make([]byte, m.quality*1000), nil
+	}
+	return make([]byte, m.blobSize(m.quality, m.GetImageWidth(), m.GetImageHeight())), nil
+}
+
+func (m *mockMagickWand) SetInterlaceScheme(scheme interlaceType) error {
+	if m.errSetInterlaceScheme != nil {
+		return m.errSetInterlaceScheme
+	}
+	m.interlaceScheme = scheme
+	return nil
+}
+
+func (m *mockMagickWand) SetOption(key, value string) error {
+	if m.options == nil {
+		m.options = make(map[string]string)
+	}
+	m.options[key] = value
+	return m.errSetOption
+}
+
+func (m *mockMagickWand) Quantize(colors uint, dither bool) error {
+	m.quantizedColors = colors
+	return m.errQuantize
+}
+
 func (m *mockMagickWand) WriteImage(filename string) error {
+	m.writtenImage = append(m.writtenImage, filename)
 	return m.errWriteImage
 }
 
+func (m *mockMagickWand) Distortion(reference magickWand, metric metricType) (float64, error) {
+	if distortion, ok := m.distortions[metric]; ok && m.errDistortion == nil {
+		return distortion, nil
+	}
+	if m.errDistortion != nil || m.dssim == nil {
+		return 0, m.errDistortion
+	}
+	return m.dssim(), nil
+}
+
+func (m *mockMagickWand) SetFuzz(percent float64) error {
+	m.fuzz = percent
+	return m.errSetFuzz
+}
+
+func (m *mockMagickWand) DiffImage(reference magickWand) (magickWand, error) {
+	if m.errDiffImage != nil {
+		return nil, m.errDiffImage
+	}
+	return m.diff, nil
+}
+
+func (m *mockMagickWand) StripImage() error {
+	m.operations = append(m.operations, "strip")
+	return m.errStripImage
+}
+
+func (m *mockMagickWand) GetImageColors() uint {
+	return m.colors
+}
+
+func (m *mockMagickWand) GetImageCompressionQuality() uint {
+	return m.inputQuality
+}
+
+func (m *mockMagickWand) ExportRGBA() ([]byte, error) {
+	if m.errExportRGBA != nil {
+		return nil, m.errExportRGBA
+	}
+	pixels := make([]byte, 0, m.GetImageWidth()*m.GetImageHeight()*4)
+	for n := uint(0); n < m.GetImageWidth()*m.GetImageHeight(); n++ {
+		pixels = append(pixels, m.rgba[:]...)
+	}
+	return pixels, nil
+}
+
+func (m *mockMagickWand) Histogram() []histogramColor {
+	if m.histogram != nil {
+		return m.histogram
+	}
+	return []histogramColor{{RGBA: m.rgba, Count: m.GetImageWidth() * m.GetImageHeight()}}
+}
+
 func (m *mockMagickWand) Destroy() {}
+
+func (m *mockMagickWand) Clear() {}
+
+func (m *mockMagickWand) GetNumberImages() uint {
+	if m.numberImages == 0 {
+		return 1
+	}
+	return m.numberImages
+}
+
+func (m *mockMagickWand) SetIteratorIndex(index int) bool {
+	return true
+}
+
+func (m *mockMagickWand) GetImageFormat() string {
+	return m.format
+}
+
+func (m *mockMagickWand) SelectFrame(index int) error {
+	return m.errSelectFrame
+}
+
+func (m *mockMagickWand) Coalesce() error {
+	return m.errCoalesce
+}
+
+func (m *mockMagickWand) OptimizeLayers() error {
+	return m.errOptimizeLayers
+}
+
+func (m *mockMagickWand) SetResolution(xRes, yRes float64) error {
+	m.densities = append(m.densities, xRes)
+	return m.errSetResolution
+}
+
+func (m *mockMagickWand) GetImageResolution() (x, y float64, err error) {
+	return m.resolution, m.resolution, nil
+}
+
+func (m *mockMagickWand) PingImage(filename string) error {
+	if m.pingFormat != "" {
+		m.format = m.pingFormat
+	}
+	return m.errPingImage
+}
+
+func (m *mockMagickWand) PingImageBlob(blob []byte) error {
+	return m.PingImage("")
+}
+
+func (m *mockMagickWand) GetImageColorspace() string {
+	return "sRGB"
+}
+
+func (m *mockMagickWand) GetImageDepth() uint {
+	return 8
+}
+
+func (m *mockMagickWand) GetImageOrientation() int {
+	return m.orientation
+}
+
+func (m *mockMagickWand) SetBackground(color string) error {
+	return m.errSetBackground
+}
+
+func (m *mockMagickWand) AppendAll(topToBottom bool) error {
+	return m.errAppendAll
+}
+
+func (m *mockMagickWand) WriteImages(filename string, adjoin bool) error {
+	m.writtenImages = filename
+	return m.errWriteImages
+}
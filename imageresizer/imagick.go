@@ -2,21 +2,124 @@
 // Use of this source code is governed by the MIT License that can be found in
 // the LICENSE file.
 
+//go:build cgo && !nomagick
+
 package imageresizer
 
-import "gopkg.in/gographics/imagick.v3/imagick"
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
+	"gopkg.in/gographics/imagick.v3/imagick"
+)
+
+// filterTypes maps each filter type to ImageMagick's.
+var filterTypes = map[FilterType]imagick.FilterType{
+	FILTER_UNDEFINED:      imagick.FILTER_UNDEFINED,
+	FILTER_POINT:          imagick.FILTER_POINT,
+	FILTER_BOX:            imagick.FILTER_BOX,
+	FILTER_TRIANGLE:       imagick.FILTER_TRIANGLE,
+	FILTER_HERMITE:        imagick.FILTER_HERMITE,
+	FILTER_HANNING:        imagick.FILTER_HANNING,
+	FILTER_HAMMING:        imagick.FILTER_HAMMING,
+	FILTER_BLACKMAN:       imagick.FILTER_BLACKMAN,
+	FILTER_GAUSSIAN:       imagick.FILTER_GAUSSIAN,
+	FILTER_QUADRATIC:      imagick.FILTER_QUADRATIC,
+	FILTER_CUBIC:          imagick.FILTER_CUBIC,
+	FILTER_CATROM:         imagick.FILTER_CATROM,
+	FILTER_MITCHELL:       imagick.FILTER_MITCHELL,
+	FILTER_JINC:           imagick.FILTER_JINC,
+	FILTER_SINC:           imagick.FILTER_SINC,
+	FILTER_SINC_FAST:      imagick.FILTER_SINC_FAST,
+	FILTER_KAISER:         imagick.FILTER_KAISER,
+	FILTER_WELSH:          imagick.FILTER_WELSH,
+	FILTER_PARZEN:         imagick.FILTER_PARZEN,
+	FILTER_BOHMAN:         imagick.FILTER_BOHMAN,
+	FILTER_BARTLETT:       imagick.FILTER_BARTLETT,
+	FILTER_LAGRANGE:       imagick.FILTER_LAGRANGE,
+	FILTER_LANCZOS:        imagick.FILTER_LANCZOS,
+	FILTER_LANCZOS_SHARP:  imagick.FILTER_LANCZOS_SHARP,
+	FILTER_LANCZOS2:       imagick.FILTER_LANCZOS2,
+	FILTER_LANCZOS2_SHARP: imagick.FILTER_LANCZOS2_SHARP,
+	FILTER_ROBIDOUX:       imagick.FILTER_ROBIDOUX,
+	FILTER_ROBIDOUX_SHARP: imagick.FILTER_ROBIDOUX_SHARP,
+	FILTER_COSINE:         imagick.FILTER_COSINE,
+	FILTER_SPLINE:         imagick.FILTER_SPLINE,
+	FILTER_LANCZOS_RADIUS: imagick.FILTER_LANCZOS_RADIUS,
+}
+
+// interlaceTypes maps each interlace scheme to ImageMagick's.
+var interlaceTypes = map[interlaceType]imagick.InterlaceType{
+	interlaceUndefined: imagick.INTERLACE_UNDEFINED,
+	interlacePlane:     imagick.INTERLACE_PLANE,
+}
+
+// alphaChannelTypes maps each alpha channel operation to ImageMagick's.
+var alphaChannelTypes = map[alphaChannelType]imagick.AlphaChannelType{
+	alphaChannelSet: imagick.ALPHA_CHANNEL_SET,
+}
+
+// channelTypes maps each set of channels to ImageMagick's channel mask.
+var channelTypes = map[channelType]imagick.ChannelType{
+	channelsDefault: imagick.CHANNELS_DEFAULT,
+	channelAlpha:    imagick.CHANNEL_ALPHA,
+}
+
+// evaluateOperators maps each arithmetic operator to ImageMagick's.
+var evaluateOperators = map[evaluateOperator]imagick.EvaluateOperator{
+	evaluateMultiply: imagick.EVAL_OP_MULTIPLY,
+}
+
+// compositeOperators maps each composite operator to ImageMagick's.
+var compositeOperators = map[compositeOperator]imagick.CompositeOperator{
+	compositeOver:    imagick.COMPOSITE_OP_OVER,
+	compositeDstOver: imagick.COMPOSITE_OP_DST_OVER,
+}
+
+// metricTypes maps each metric to ImageMagick's.
+var metricTypes = map[metricType]imagick.MetricType{
+	metricAbsoluteError:           imagick.METRIC_ABSOLUTE_ERROR,
+	metricRootMeanSquaredError:    imagick.METRIC_ROOT_MEAN_SQUARED_ERROR,
+	metricPeakSignalToNoiseRatio:  imagick.METRIC_PEAK_SIGNAL_TO_NOISE_RATIO,
+	metricStructuralDissimilarity: imagick.METRIC_STRUCTURAL_DISSIMILARITY_ERROR,
+}
 
-// magickWand defines an interface for working with ImageMagick's MagickWand API.
-// It abstracts the operations needed for resizing images, allowing for easier testing
-// and potential future extensions or replacements of the underlying ImageMagick library.
-type magickWand interface {
-	ReadImage(filename string) error                                   // ReadImage loads an image from the specified file.
-	ResizeImage(cols uint, rows uint, filter imagick.FilterType) error // ResizeImage resizes the image using the specified dimensions and filter.
-	GetImageWidth() uint                                               // GetImageWidth returns the width of the current image.
-	GetImageHeight() uint                                              // GetImageHeight returns the height of the current image.
-	SetImageCompressionQuality(quality uint) error                     // SetImageCompressionQuality sets the compression quality of the image.
-	WriteImage(filename string) error                                  // WriteImage writes the image to the specified file.
-	Destroy()                                                          // Destroy releases resources associated with the MagickWand.
+// colorspaceNames maps each ImageMagick colorspace to its name.
+var colorspaceNames = map[imagick.ColorspaceType]string{
+	imagick.COLORSPACE_CMY:         "CMY",
+	imagick.COLORSPACE_CMYK:        "CMYK",
+	imagick.COLORSPACE_GRAY:        "Gray",
+	imagick.COLORSPACE_HCL:         "HCL",
+	imagick.COLORSPACE_HCLP:        "HCLp",
+	imagick.COLORSPACE_HSB:         "HSB",
+	imagick.COLORSPACE_HSI:         "HSI",
+	imagick.COLORSPACE_HSL:         "HSL",
+	imagick.COLORSPACE_HSV:         "HSV",
+	imagick.COLORSPACE_HWB:         "HWB",
+	imagick.COLORSPACE_LAB:         "Lab",
+	imagick.COLORSPACE_LCH:         "LCH",
+	imagick.COLORSPACE_LCHAB:       "LCHab",
+	imagick.COLORSPACE_LCHUV:       "LCHuv",
+	imagick.COLORSPACE_LMS:         "LMS",
+	imagick.COLORSPACE_LOG:         "Log",
+	imagick.COLORSPACE_LUV:         "Luv",
+	imagick.COLORSPACE_OHTA:        "OHTA",
+	imagick.COLORSPACE_REC601YCBCR: "Rec601YCbCr",
+	imagick.COLORSPACE_REC709YCBCR: "Rec709YCbCr",
+	imagick.COLORSPACE_RGB:         "RGB",
+	imagick.COLORSPACE_SCRGB:       "scRGB",
+	imagick.COLORSPACE_SRGB:        "sRGB",
+	imagick.COLORSPACE_TRANSPARENT: "Transparent",
+	imagick.COLORSPACE_XYY:         "xyY",
+	imagick.COLORSPACE_XYZ:         "XYZ",
+	imagick.COLORSPACE_YCBCR:       "YCbCr",
+	imagick.COLORSPACE_YCC:         "YCC",
+	imagick.COLORSPACE_YDDDR:       "YDbDr",
+	imagick.COLORSPACE_YIQ:         "YIQ",
+	imagick.COLORSPACE_YPBPR:       "YPbPr",
+	imagick.COLORSPACE_YUV:         "YUV",
 }
 
 // magickWandWrapper implements the magickWand interface and serves as a wrapper
@@ -26,3 +129,398 @@ type magickWand interface {
 type magickWandWrapper struct {
 	*imagick.MagickWand // Embedding *imagick.MagickWand to provide direct access to its methods.
 }
+
+// newMagickWandWrapper returns a magickWand backed by a new *imagick.MagickWand.
+func newMagickWandWrapper() magickWand {
+	return &magickWandWrapper{imagick.NewMagickWand()}
+}
+
+// defaultBackend initializes the ImageMagick environment and returns the function creating
+// the wands of resizers that do not process images in memory.
+func defaultBackend() func() magickWand {
+	imagick.Initialize()
+	if err := restoreSecurityPolicies(); err != nil {
+		// Images must not be read without the policies, which were valid when they were set.
+		return func() magickWand {
+			return unreadableWand{Wand: newMagickWandWrapper(), err: err}
+		}
+	}
+	return newMagickWandWrapper
+}
+
+// Terminate releases resources used by imageResizer and ImageMagick. It is the responsibility
+// of the caller to invoke this function after completing image resizing operations. Failing to
+// call Terminate can lead to resource leaks as it cleans up the MagickWand instance and
+// terminates the ImageMagick environment. This is crucial especially in long-running
+// applications or those processing large numbers of images, to avoid excessive memory usage.
+// The policies set with SetSecurityPolicy are unloaded too, and applied again when the next
+// resizer is created.
+func Terminate() {
+	securityPolicies.Lock()
+	defer securityPolicies.Unlock()
+	imagick.Terminate()
+	securityPolicies.unloaded = true // The next initialization reloads the system policy only.
+}
+
+// ResizeImage resizes the wrapped wand's image to cols x rows with the given filter.
+func (mw *magickWandWrapper) ResizeImage(cols uint, rows uint, filter wand.Filter) error {
+	imagickFilter, ok := filterTypes[FilterType(filter)]
+	if !ok {
+		return FilterType(filter).validate()
+	}
+	return mw.MagickWand.ResizeImage(cols, rows, imagickFilter)
+}
+
+// SetInterlaceScheme sets the interlace scheme the wrapped wand's images are encoded with.
+func (mw *magickWandWrapper) SetInterlaceScheme(scheme interlaceType) error {
+	return mw.MagickWand.SetInterlaceScheme(interlaceTypes[scheme])
+}
+
+// GetImageColorspace returns the name of the colorspace of the wrapped wand's image, or an
+// empty string for colorspaces without one.
+func (mw *magickWandWrapper) GetImageColorspace() string {
+	return colorspaceNames[mw.MagickWand.GetImageColorspace()]
+}
+
+// GetImageOrientation returns the EXIF orientation of the wrapped wand's image, which
+// ImageMagick's orientation types are numbered after.
+func (mw *magickWandWrapper) GetImageOrientation() int {
+	return int(mw.MagickWand.GetImageOrientation())
+}
+
+// SetImageAlphaChannel applies the given operation to the alpha channel of the wrapped wand's image.
+func (mw *magickWandWrapper) SetImageAlphaChannel(operation alphaChannelType) error {
+	return mw.MagickWand.SetImageAlphaChannel(alphaChannelTypes[operation])
+}
+
+// SetImageChannelMask restricts the following operations on the wrapped wand's image to the
+// given channels, returning the previous mask. Masks other than the ones of this package,
+// which the wand is never given, are reported as the default one.
+func (mw *magickWandWrapper) SetImageChannelMask(channel channelType) channelType {
+	previous := mw.MagickWand.SetImageChannelMask(channelTypes[channel])
+	for local, mask := range channelTypes {
+		if mask == previous {
+			return local
+		}
+	}
+	return channelsDefault
+}
+
+// EvaluateImage applies the arithmetic operator, with value, to the channels of the wrapped
+// wand's image.
+func (mw *magickWandWrapper) EvaluateImage(op evaluateOperator, value float64) error {
+	return mw.MagickWand.EvaluateImage(evaluateOperators[op], value)
+}
+
+// Composite draws the image of source over the wrapped wand's image at the given position.
+// The source must be a *magickWandWrapper, as the underlying API works on *imagick.MagickWand.
+func (mw *magickWandWrapper) Composite(source magickWand, compose compositeOperator, x, y int) error {
+	src, ok := source.(*magickWandWrapper)
+	if !ok {
+		return fmt.Errorf("unsupported composite source %T", source)
+	}
+	return mw.CompositeImage(src.MagickWand, compositeOperators[compose], true, x, y)
+}
+
+// SelectFrame replaces the images of the wrapped wand by the frame at index, coalesced
+// into a full picture.
+func (mw *magickWandWrapper) SelectFrame(index int) error {
+	if index < 0 || index >= int(mw.GetNumberImages()) {
+		return fmt.Errorf("image has %d frames", mw.GetNumberImages())
+	}
+	if err := mw.Coalesce(); err != nil {
+		return err
+	}
+	mw.SetIteratorIndex(index)
+	return mw.replaceWand(mw.GetImage())
+}
+
+// Coalesce replaces the images of the wrapped wand by their coalesced version, in which every
+// frame is a full picture.
+func (mw *magickWandWrapper) Coalesce() error {
+	return mw.replaceWand(mw.CoalesceImages())
+}
+
+// OptimizeLayers replaces the images of the wrapped wand by their optimized layers, in which
+// every frame holds only the area that differs from the previous one.
+func (mw *magickWandWrapper) OptimizeLayers() error {
+	return mw.replaceWand(mw.OptimizeImageLayers())
+}
+
+// AppendAll replaces the images of the wrapped wand by a single image in which they are
+// appended, either side by side or from top to bottom.
+func (mw *magickWandWrapper) AppendAll(topToBottom bool) error {
+	mw.SetFirstIterator() // Append from the first image onwards.
+	return mw.replaceWand(mw.AppendImages(topToBottom))
+}
+
+// replaceWand replaces the wrapped wand by result, the wand returned by an operation that
+// produces new images, reporting the operation's error if it did not produce any.
+func (mw *magickWandWrapper) replaceWand(result *imagick.MagickWand) error {
+	if !result.IsVerified() {
+		if err := mw.GetLastError(); err != nil {
+			return err
+		}
+		return fmt.Errorf("operation produced no images")
+	}
+	mw.MagickWand.Destroy()
+	mw.MagickWand = result
+	return nil
+}
+
+// SetBackground sets the background color of the wrapped wand, over which vector images
+// are rasterized when read.
+func (mw *magickWandWrapper) SetBackground(color string) error {
+	pw := imagick.NewPixelWand()
+	defer pw.Destroy()
+	if !pw.SetColor(color) {
+		return fmt.Errorf("invalid color %q", color)
+	}
+	return mw.SetBackgroundColor(pw)
+}
+
+// Quantize reduces the wrapped wand's image to a palette of at most colors, dithering it
+// with the Floyd-Steinberg method if dither is set.
+func (mw *magickWandWrapper) Quantize(colors uint, dither bool) error {
+	method := imagick.DITHER_METHOD_NO
+	if dither {
+		method = imagick.DITHER_METHOD_FLOYD_STEINBERG
+	}
+	return mw.QuantizeImage(colors, imagick.COLORSPACE_SRGB, 0, method, false)
+}
+
+// GetImageBlob returns the wrapped wand's current image encoded in memory, in its image format.
+func (mw *magickWandWrapper) GetImageBlob() ([]byte, error) {
+	blob := mw.MagickWand.GetImageBlob()
+	if len(blob) == 0 {
+		if err := mw.GetLastError(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("image could not be encoded")
+	}
+	return blob, nil
+}
+
+// Distortion returns how much the wrapped wand's image differs from the reference image,
+// according to the given metric.
+func (mw *magickWandWrapper) Distortion(reference magickWand, metric metricType) (float64, error) {
+	ref, ok := reference.(*magickWandWrapper)
+	if !ok {
+		return 0, fmt.Errorf("unsupported reference %T", reference)
+	}
+	return mw.GetImageDistortion(ref.MagickWand, metricTypes[metric])
+}
+
+// ExportRGBA returns the pixels of the wrapped wand's current image as 8-bit RGBA values,
+// row by row.
+func (mw *magickWandWrapper) ExportRGBA() ([]byte, error) {
+	pixels, err := mw.ExportImagePixels(0, 0, mw.GetImageWidth(), mw.GetImageHeight(), "RGBA", imagick.PIXEL_CHAR)
+	if err != nil {
+		return nil, err
+	}
+	return pixels.([]byte), nil
+}
+
+// Histogram returns the distinct colors of the wrapped wand's current image as 8-bit RGBA
+// values, along with the number of pixels having each one.
+func (mw *magickWandWrapper) Histogram() []histogramColor {
+	_, pws := mw.GetImageHistogram()
+	colors := make([]histogramColor, len(pws))
+	for n, pw := range pws {
+		colors[n] = histogramColor{
+			RGBA: [4]uint8{
+				uint8(math.Round(pw.GetRed() * 255)),
+				uint8(math.Round(pw.GetGreen() * 255)),
+				uint8(math.Round(pw.GetBlue() * 255)),
+				uint8(math.Round(pw.GetAlpha() * 255)),
+			},
+			Count: pw.GetColorCount(),
+		}
+		pw.Destroy()
+	}
+	return colors
+}
+
+// SetFuzz sets the fuzz of the wrapped wand's image to the given percentage of the quantum
+// range: colors closer than that count as equal when comparing images.
+func (mw *magickWandWrapper) SetFuzz(percent float64) error {
+	_, quantumRange := imagick.GetQuantumRange()
+	return mw.SetImageFuzz(percent / 100 * float64(quantumRange))
+}
+
+// DiffImage returns a wand holding an image that highlights, in red, the pixels of the wrapped
+// wand's image that differ from the reference image.
+func (mw *magickWandWrapper) DiffImage(reference magickWand) (magickWand, error) {
+	ref, ok := reference.(*magickWandWrapper)
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %T", reference)
+	}
+	diff, _ := mw.CompareImages(ref.MagickWand, imagick.METRIC_ABSOLUTE_ERROR)
+	if !diff.IsVerified() {
+		if err := mw.GetLastError(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("images could not be compared")
+	}
+	return &magickWandWrapper{diff}, nil
+}
+
+// CloneWand returns a copy of the wrapped wand and its images.
+func (mw *magickWandWrapper) CloneWand() magickWand {
+	return &magickWandWrapper{mw.Clone()}
+}
+
+// CloneImage returns a new wand holding a copy of the wrapped wand's current image only,
+// along with the settings of the wand.
+func (mw *magickWandWrapper) CloneImage() magickWand {
+	return &magickWandWrapper{mw.GetImage()}
+}
+
+// Extend enlarges the canvas of the wrapped wand's image to width x height, placing the image
+// at x, y. The new area is filled with the background color, which may be translucent.
+func (mw *magickWandWrapper) Extend(width, height uint, x, y int, background string) error {
+	pw := imagick.NewPixelWand()
+	defer pw.Destroy()
+	if !pw.SetColor(background) {
+		return fmt.Errorf("invalid color %q", background)
+	}
+	if pw.GetAlpha() < 1 && !mw.GetImageAlphaChannel() {
+		if err := mw.SetImageAlphaChannel(alphaChannelSet); err != nil {
+			return err
+		}
+	}
+	if err := mw.SetImageBackgroundColor(pw); err != nil {
+		return err
+	}
+	return mw.ExtentImage(width, height, -x, -y)
+}
+
+// TrimBorders removes the borders of the wrapped wand's image that match color, within fuzz,
+// a tolerance from 0 to 1. When color is empty, the border color is detected from the image
+// corners. It returns the color of the removed borders.
+func (mw *magickWandWrapper) TrimBorders(color string, fuzz float64) (string, error) {
+	pw := imagick.NewPixelWand()
+	defer pw.Destroy()
+	if color == "" {
+		corner, err := mw.GetImagePixelColor(0, 0)
+		if err != nil {
+			return "", err
+		}
+		defer corner.Destroy()
+		color = corner.GetColorAsString()
+	} else {
+		if !pw.SetColor(color) {
+			return "", fmt.Errorf("invalid color %q", color)
+		}
+		// A one pixel frame of the given color makes ImageMagick pick it as the border to trim.
+		if err := mw.BorderImage(pw, 1, 1, imagick.COMPOSITE_OP_COPY); err != nil {
+			return "", err
+		}
+	}
+	_, quantumRange := imagick.GetQuantumRange()
+	if err := mw.TrimImage(fuzz * float64(quantumRange)); err != nil {
+		return "", err
+	}
+	return color, mw.ResetImagePage("")
+}
+
+// Rotate rotates the wrapped wand's image clockwise by degrees, filling the uncovered corners
+// with the background color, which may be translucent.
+func (mw *magickWandWrapper) Rotate(degrees float64, background string) error {
+	pw := imagick.NewPixelWand()
+	defer pw.Destroy()
+	if !pw.SetColor(background) {
+		return fmt.Errorf("invalid color %q", background)
+	}
+	if pw.GetAlpha() < 1 && !mw.GetImageAlphaChannel() {
+		if err := mw.SetImageAlphaChannel(alphaChannelSet); err != nil {
+			return err
+		}
+	}
+	if err := mw.RotateImage(pw, degrees); err != nil {
+		return err
+	}
+	return mw.ResetImagePage("")
+}
+
+// TextMetrics measures text as it would be drawn on the wrapped wand's image using style.
+func (mw *magickWandWrapper) TextMetrics(style textStyle, text string) (textMetrics, error) {
+	dw, destroy, err := newDrawingWand(style)
+	if err != nil {
+		return textMetrics{}, err
+	}
+	defer destroy()
+	metrics := mw.QueryFontMetrics(dw, text)
+	if metrics == nil {
+		return textMetrics{}, fmt.Errorf("could not query font metrics")
+	}
+	return textMetrics{
+		Width:    metrics.TextWidth,
+		Height:   metrics.TextHeight,
+		Ascender: metrics.Ascender,
+	}, nil
+}
+
+// Annotate draws text on the wrapped wand's image using style, with its baseline starting at x, y.
+func (mw *magickWandWrapper) Annotate(style textStyle, x, y float64, text string) error {
+	dw, destroy, err := newDrawingWand(style)
+	if err != nil {
+		return err
+	}
+	defer destroy()
+	return mw.AnnotateImage(dw, x, y, 0, text)
+}
+
+// newDrawingWand returns a drawing wand configured with style, along with a function that
+// releases it and the pixel wands holding its colors.
+func newDrawingWand(style textStyle) (*imagick.DrawingWand, func(), error) {
+	dw := imagick.NewDrawingWand()
+	var pixelWands []*imagick.PixelWand
+	destroy := func() {
+		for _, pw := range pixelWands {
+			pw.Destroy()
+		}
+		dw.Destroy()
+	}
+	newPixelWand := func(color string) (*imagick.PixelWand, error) {
+		pw := imagick.NewPixelWand()
+		pixelWands = append(pixelWands, pw)
+		if !pw.SetColor(color) {
+			return nil, fmt.Errorf("invalid color %q", color)
+		}
+		return pw, nil
+	}
+	if style.Font != "" {
+		if err := dw.SetFont(style.Font); err != nil {
+			destroy()
+			return nil, nil, errors.Wrapf(err, "setting font %s", style.Font)
+		}
+	}
+	if style.FontSize > 0 {
+		dw.SetFontSize(style.FontSize)
+	}
+	fill, err := newPixelWand(style.Color)
+	if err != nil {
+		destroy()
+		return nil, nil, err
+	}
+	dw.SetFillColor(fill)
+	if style.StrokeColor != "" {
+		stroke, err := newPixelWand(style.StrokeColor)
+		if err != nil {
+			destroy()
+			return nil, nil, err
+		}
+		dw.SetStrokeColor(stroke)
+		dw.SetStrokeWidth(style.StrokeWidth)
+	}
+	if style.BackgroundColor != "" {
+		under, err := newPixelWand(style.BackgroundColor)
+		if err != nil {
+			destroy()
+			return nil, nil, err
+		}
+		dw.SetTextUnderColor(under)
+	}
+	return dw, destroy, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build cgo && !nomagick
+
+package imageresizer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// withImageMagick tells the tests shared by both builds that the package is built with
+// ImageMagick.
+const withImageMagick = true
+
+func Test_filterTypes(t *testing.T) {
+	for filter := FILTER_UNDEFINED; filter <= FILTER_LANCZOS_RADIUS; filter++ {
+		_, ok := filterTypes[filter]
+		require.True(t, ok, "filter %d is not mapped to ImageMagick's", filter)
+	}
+}
+
+func Test_metricTypes(t *testing.T) {
+	for _, metric := range []metricType{metricAbsoluteError, metricRootMeanSquaredError, metricPeakSignalToNoiseRatio, metricStructuralDissimilarity} {
+		_, ok := metricTypes[metric]
+		require.True(t, ok, "metric %d is not mapped to ImageMagick's", metric)
+	}
+}
+
+// TestSetSecurityPolicy restricts the policy of the whole test process, which the tests of
+// real images running after it must comply with.
+func TestSetSecurityPolicy(t *testing.T) {
+	require.NoError(t, SetSecurityPolicy(UntrustedInputPolicy))
+	testCases := []struct {
+		name          string
+		input         string
+		expectedError string
+	}{
+		{name: "png", input: filepath.Join("testdata", "fixtures", "pattern.png")},
+		{name: "mvg referencing a file", input: filepath.Join("testdata", "seeds", "external_reference.mvg"), expectedError: "not authorized"},
+		{name: "svg referencing a file", input: filepath.Join("testdata", "seeds", "external_reference.svg"), expectedError: "not authorized"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resizeUnderPolicy(t, tc.input, tc.expectedError)
+		})
+	}
+	t.Run("after Terminate", func(t *testing.T) {
+		// ImageMagick reloads its system policy.xml when initialized again, without the rules set.
+		Terminate()
+		resizeUnderPolicy(t, filepath.Join("testdata", "seeds", "external_reference.mvg"), "not authorized")
+	})
+}
+
+// resizeUnderPolicy resizes the image at input, which must fail with expectedError, if set.
+func resizeUnderPolicy(t *testing.T, input, expectedError string) {
+	t.Helper()
+	ir := New(WithDimensions(8, 8), WithOutputDir(t.TempDir()))
+	defer ir.Destroy()
+	_, err := ir.Resize(input)
+	if expectedError != "" {
+		require.Error(t, err)
+		require.Contains(t, err.Error(), expectedError)
+	} else {
+		require.NoError(t, err)
+	}
+}
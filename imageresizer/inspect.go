@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ImageInfo describes an image as read from its header, without decoding its pixels.
+type ImageInfo struct {
+	Format      string  // Format of the image, e.g. "JPEG".
+	Width       int     // Width of the first frame or page, in pixels, as stored.
+	Height      int     // Height of the first frame or page, in pixels, as stored.
+	Frames      int     // Number of frames or pages.
+	Colorspace  string  // Colorspace of the image, named as ImageMagick does, e.g. "sRGB" or "CMYK".
+	Depth       int     // Bits per channel.
+	Orientation int     // EXIF orientation, from 1 to 8; zero when unknown.
+	Resolution  float64 // Horizontal resolution, in pixels per inch or centimeter as stored; zero when unknown.
+	Size        int64   // Size of the file, in bytes.
+}
+
+// Inspect pings the image located at imageFilePath, which only reads its header, and returns
+// its attributes along with the size of the file. It creates a resizer with default options
+// for that purpose, which is destroyed before returning.
+func Inspect(imageFilePath string) (ImageInfo, error) {
+	ir := New().(*imageResizer)
+	defer ir.Destroy()
+	return ir.Inspect(imageFilePath)
+}
+
+// InspectReader reads the whole image provided by r and pings it, returning its attributes
+// along with the number of bytes read. It creates a resizer with default options for that
+// purpose, which is destroyed before returning.
+func InspectReader(r io.Reader) (ImageInfo, error) {
+	ir := New().(*imageResizer)
+	defer ir.Destroy()
+	return ir.InspectReader(r)
+}
+
+// Inspect pings the image located at imageFilePath, which only reads its header, and returns
+// its attributes along with the size of the file.
+func (i *imageResizer) Inspect(imageFilePath string) (_ ImageInfo, err error) {
+	defer recoverPanic(&err)
+	file, err := os.Stat(imageFilePath)
+	if err != nil {
+		return ImageInfo{}, errors.Wrapf(err, "reading size of %s", imageFilePath)
+	}
+	info, err := i.ping(imageFilePath)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info.Size = file.Size()
+	return info, nil
+}
+
+// InspectReader reads the whole image provided by r and pings it, returning its attributes
+// along with the number of bytes read.
+func (i *imageResizer) InspectReader(r io.Reader) (_ ImageInfo, err error) {
+	defer recoverPanic(&err)
+	blob, err := io.ReadAll(r)
+	if err != nil {
+		return ImageInfo{}, errors.Wrap(err, "reading image")
+	}
+	i.mw.Clear()
+	defer i.mw.Clear() // Pinging leaves the image, without its pixels, in the wand.
+	if err := i.mw.PingImageBlob(blob); err != nil {
+		return ImageInfo{}, errors.Wrap(err, "pinging image")
+	}
+	info, err := i.imageInfo()
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info.Size = int64(len(blob))
+	return info, nil
+}
+
+// ping returns the attributes of the image located at imageFilePath, which may carry a page
+// selector, reading its header only. The size of the file is not set.
+func (i *imageResizer) ping(imageFilePath string) (ImageInfo, error) {
+	i.mw.Clear()
+	defer i.mw.Clear() // Pinging leaves the image, without its pixels, in the wand.
+	if err := i.mw.PingImage(imageFilePath); err != nil {
+		return ImageInfo{}, errors.Wrapf(err, "pinging image %s", imageFilePath)
+	}
+	return i.imageInfo()
+}
+
+// imageInfo returns the attributes of the pinged image, as found on its first frame or page.
+func (i *imageResizer) imageInfo() (ImageInfo, error) {
+	frames := int(i.mw.GetNumberImages())
+	i.mw.SetIteratorIndex(0)
+	resolution, _, err := i.mw.GetImageResolution()
+	if err != nil {
+		return ImageInfo{}, errors.Wrap(err, "getting image resolution")
+	}
+	return ImageInfo{
+		Format:      i.mw.GetImageFormat(),
+		Width:       int(i.mw.GetImageWidth()),
+		Height:      int(i.mw.GetImageHeight()),
+		Frames:      frames,
+		Colorspace:  i.mw.GetImageColorspace(),
+		Depth:       int(i.mw.GetImageDepth()),
+		Orientation: int(i.mw.GetImageOrientation()),
+		Resolution:  resolution,
+	}, nil
+}
+
+// transposed reports whether the EXIF orientation of the image swaps its width and height
+// when it is displayed, which is the case of orientations 5 to 8.
+func (info ImageInfo) transposed() bool {
+	return info.Orientation >= 5 && info.Orientation <= 8
+}
+
+// orientedDimensions returns the dimensions of the image as displayed, following its EXIF
+// orientation.
+func (info ImageInfo) orientedDimensions() (int, int) {
+	if info.transposed() {
+		return info.Height, info.Width
+	}
+	return info.Width, info.Height
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func(m *mockMagickWand)
+		missingFile   bool
+		expectedInfo  ImageInfo
+		expectedError string
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "JPEG"
+				m.resolution = 300
+				m.orientation = 6
+			},
+			expectedInfo: ImageInfo{
+				Format:      "JPEG",
+				Width:       1200,
+				Height:      850,
+				Frames:      1,
+				Colorspace:  "sRGB",
+				Depth:       8,
+				Orientation: 6,
+				Resolution:  300,
+				Size:        2048,
+			},
+		},
+		{
+			name: "multi-page image",
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "PDF"
+				m.numberImages = 3
+			},
+			expectedInfo: ImageInfo{
+				Format:     "PDF",
+				Width:      1200,
+				Height:     850,
+				Frames:     3,
+				Colorspace: "sRGB",
+				Depth:      8,
+				Size:       2048,
+			},
+		},
+		{
+			name:          "error when reading size",
+			missingFile:   true,
+			expectedError: "reading size of %s: stat %s: no such file or directory",
+		},
+		{
+			name: "error when pinging image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errPingImage = errors.New("ping image error")
+			},
+			expectedError: "pinging image %s: ping image error",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imageFilePath := filepath.Join(t.TempDir(), "someImage.jpg")
+			if !tc.missingFile {
+				require.NoError(t, os.WriteFile(imageFilePath, make([]byte, 2048), 0o644))
+			}
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{mw: m}
+			info, err := ir.Inspect(imageFilePath)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, strings.ReplaceAll(tc.expectedError, "%s", imageFilePath), err.Error())
+			} else {
+				if tc.expectedError != "" {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedInfo, info)
+			}
+		})
+	}
+}
+
+func TestInspectReader(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func(m *mockMagickWand)
+		expectedInfo  ImageInfo
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "PNG"
+			},
+			expectedInfo: ImageInfo{
+				Format:     "PNG",
+				Width:      1200,
+				Height:     850,
+				Frames:     1,
+				Colorspace: "sRGB",
+				Depth:      8,
+				Size:       5,
+			},
+		},
+		{
+			name: "error when pinging image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errPingImage = errors.New("ping image error")
+			},
+			expectedError: errors.New("pinging image: ping image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			tc.mockClosure(m)
+			ir := &imageResizer{mw: m}
+			info, err := ir.InspectReader(strings.NewReader("image"))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedInfo, info)
+			}
+		})
+	}
+}
+
+func TestInspectFunctions(t *testing.T) {
+	if !withImageMagick {
+		t.Skip("the package-level functions need ImageMagick; see TestNoImageMagick")
+	}
+	imageFilePath := filepath.Join("testdata", "fixtures", "pattern.png")
+	t.Run("Inspect", func(t *testing.T) {
+		info, err := Inspect(imageFilePath)
+		require.NoError(t, err)
+		require.Equal(t, "PNG", info.Format)
+		require.Equal(t, 96, info.Width)
+		require.Equal(t, 64, info.Height)
+		require.Equal(t, 1, info.Frames)
+		require.Equal(t, int64(997), info.Size)
+	})
+	t.Run("InspectReader", func(t *testing.T) {
+		file, err := os.Open(imageFilePath)
+		require.NoError(t, err)
+		defer file.Close()
+		info, err := InspectReader(file)
+		require.NoError(t, err)
+		require.Equal(t, "PNG", info.Format)
+		require.Equal(t, 96, info.Width)
+		require.Equal(t, 64, info.Height)
+		require.Equal(t, int64(997), info.Size)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Inspect(filepath.Join("testdata", "fixtures", "missing.png"))
+		require.Error(t, err)
+	})
+}
+
+func Test_read(t *testing.T) {
+	testCases := []struct {
+		name          string
+		pages         *pageRange
+		mockClosure   func(m *mockMagickWand)
+		expectedInfo  ImageInfo
+		expectedError error
+	}{
+		{
+			name: "no dimensions",
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "JPEG"
+				m.orientation = 6
+			},
+			expectedInfo: ImageInfo{Format: "JPEG", Width: 1200, Height: 850, Frames: 1, Colorspace: "sRGB", Depth: 8, Orientation: 6},
+		},
+		{
+			name:  "pages",
+			pages: &pageRange{first: 2, last: 3},
+			mockClosure: func(m *mockMagickWand) {
+				m.pingFormat = "PDF"
+				m.numberImages = 2
+			},
+			expectedInfo: ImageInfo{Format: "PDF", Width: 1200, Height: 850, Frames: 2, Colorspace: "sRGB", Depth: 8},
+		},
+		{
+			name:  "error when pinging image",
+			pages: &pageRange{first: 2, last: 3},
+			mockClosure: func(m *mockMagickWand) {
+				m.errPingImage = errors.New("ping image error")
+			},
+			expectedError: errors.New("pinging image doc.pdf[1-2]: ping image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			tc.mockClosure(m)
+			ir := &imageResizer{
+				mw:    m,
+				pages: tc.pages,
+			}
+			err := ir.read("doc.pdf")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedInfo, ir.source)
+			}
+		})
+	}
+}
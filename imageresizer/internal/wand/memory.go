@@ -0,0 +1,833 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package wand
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	colorpalette "image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// memoryDefaultQuality is the JPEG quality of the in-memory backend when none is set,
+	// the same as ImageMagick's.
+	memoryDefaultQuality = 92
+	// memoryResolution is the resolution, in DPI, the in-memory backend reports for images
+	// read without one set.
+	memoryResolution = 72
+	// memoryMaxPixels is the number of pixels, across all frames, beyond which the in-memory
+	// backend refuses to decode an image, so that a tiny file can't exhaust the memory.
+	memoryMaxPixels = 1 << 24
+)
+
+// errNoImage is returned by the in-memory backend for operations on a wand without images.
+var errNoImage = errors.New("no image in the wand")
+
+// memoryWand implements the Wand interface with Go's image packages, without ImageMagick.
+// It backs imageresizer.WithMemoryBackend, so that tests run where ImageMagick is not
+// installed. Only PNG, JPEG and GIF are decoded and encoded; writing other formats fails.
+// Resizing uses the nearest neighbour whatever the filter, rotations are limited to multiples
+// of 90 degrees, EXIF orientations are not read, and effects such as blurring, sharpening,
+// opacity and text are not rendered.
+type memoryWand struct {
+	frames      []*image.NRGBA    // Images of the wand, such as the frames of a GIF, drawn in full.
+	index       int               // Index of the current image.
+	format      string            // Format of the images, e.g. "PNG".
+	quality     uint              // Compression quality the images are encoded with.
+	options     map[string]string // Encoder and decoder options, which have no effect.
+	resolution  float64           // Resolution set before reading, in DPI; zero for none.
+	fuzz        float64           // Fraction of the color range within which colors compare as equal.
+	channelMask ChannelType       // Channels operations are restricted to, which has no effect.
+}
+
+// NewMemory returns an empty in-memory wand.
+func NewMemory() Wand {
+	return &memoryWand{options: make(map[string]string)}
+}
+
+// current returns the current image of the wand, or nil if there is none.
+func (mw *memoryWand) current() *image.NRGBA {
+	if mw.index < 0 || mw.index >= len(mw.frames) {
+		return nil
+	}
+	return mw.frames[mw.index]
+}
+
+// replace replaces the current image of the wand with img.
+func (mw *memoryWand) replace(img *image.NRGBA) {
+	mw.frames[mw.index] = img
+}
+
+func (mw *memoryWand) ReadImage(filename string) error {
+	// Frame selectors, such as "[0-2]", only apply to multi-page formats that are not decoded.
+	if n := strings.LastIndex(filename, "["); n > 0 && strings.HasSuffix(filename, "]") {
+		filename = filename[:n]
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return mw.ReadImageBlob(data)
+}
+
+func (mw *memoryWand) ReadImageBlob(blob []byte) error {
+	frames, format, err := decodeFrames(blob)
+	if err != nil {
+		return err
+	}
+	mw.frames = append(mw.frames, frames...)
+	mw.index = len(mw.frames) - 1
+	mw.format = format
+	return nil
+}
+
+func (mw *memoryWand) ResizeImage(cols uint, rows uint, filter Filter) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	if cols == 0 || rows == 0 {
+		return fmt.Errorf("invalid dimensions %dx%d", cols, rows)
+	}
+	resized := image.NewNRGBA(image.Rect(0, 0, int(cols), int(rows)))
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	for y := 0; y < int(rows); y++ {
+		for x := 0; x < int(cols); x++ {
+			// Each pixel takes the color of the source pixel under its center.
+			resized.SetNRGBA(x, y, img.NRGBAAt((2*x+1)*width/(2*int(cols)), (2*y+1)*height/(2*int(rows))))
+		}
+	}
+	mw.replace(resized)
+	return nil
+}
+
+func (mw *memoryWand) GetImageWidth() uint {
+	if img := mw.current(); img != nil {
+		return uint(img.Bounds().Dx())
+	}
+	return 0
+}
+
+func (mw *memoryWand) GetImageHeight() uint {
+	if img := mw.current(); img != nil {
+		return uint(img.Bounds().Dy())
+	}
+	return 0
+}
+
+func (mw *memoryWand) SetImageCompressionQuality(quality uint) error {
+	mw.quality = quality
+	return nil
+}
+
+func (mw *memoryWand) SetInterlaceScheme(scheme InterlaceType) error {
+	return nil
+}
+
+func (mw *memoryWand) SetOption(key, value string) error {
+	mw.options[key] = value
+	return nil
+}
+
+// Quantize maps every pixel of the current image to the nearest of its most common colors.
+func (mw *memoryWand) Quantize(colors uint, dither bool) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	histogram := mw.Histogram()
+	if len(histogram) <= int(colors) {
+		return nil
+	}
+	kept := histogram[:colors]
+	for n := 0; n < len(img.Pix); n += 4 {
+		pixel := img.Pix[n : n+4]
+		nearest, nearestDistance := 0, math.MaxInt
+		for k, c := range kept {
+			distance := 0
+			for channel := range pixel {
+				d := int(pixel[channel]) - int(c.RGBA[channel])
+				distance += d * d
+			}
+			if distance < nearestDistance {
+				nearest, nearestDistance = k, distance
+			}
+		}
+		copy(pixel, kept[nearest].RGBA[:])
+	}
+	return nil
+}
+
+func (mw *memoryWand) SetImageFormat(format string) error {
+	mw.format = strings.ToUpper(format)
+	return nil
+}
+
+func (mw *memoryWand) GetImageBlob() ([]byte, error) {
+	img := mw.current()
+	if img == nil {
+		return nil, errNoImage
+	}
+	return encodeImage(img, mw.format, mw.quality)
+}
+
+func (mw *memoryWand) StripImage() error {
+	return nil
+}
+
+func (mw *memoryWand) GetImageColors() uint {
+	return uint(len(mw.Histogram()))
+}
+
+func (mw *memoryWand) GetImageCompressionQuality() uint {
+	return mw.quality
+}
+
+func (mw *memoryWand) GetImageColorspace() string {
+	return "sRGB"
+}
+
+func (mw *memoryWand) GetImageDepth() uint {
+	return 8
+}
+
+func (mw *memoryWand) GetImageOrientation() int {
+	return 0
+}
+
+// WriteImage writes the current image to filename, in the format of its extension, if any.
+func (mw *memoryWand) WriteImage(filename string) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	format := mw.format
+	if filepath.Ext(filename) != "" {
+		format = FormatOf(filename)
+	}
+	data, err := encodeImage(img, format, mw.quality)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+func (mw *memoryWand) Destroy() {
+	mw.Clear()
+}
+
+func (mw *memoryWand) Clear() {
+	mw.frames, mw.index = nil, 0
+}
+
+func (mw *memoryWand) GetNumberImages() uint {
+	return uint(len(mw.frames))
+}
+
+func (mw *memoryWand) SetIteratorIndex(index int) bool {
+	if index < 0 || index >= len(mw.frames) {
+		return false
+	}
+	mw.index = index
+	return true
+}
+
+func (mw *memoryWand) GetImageFormat() string {
+	return mw.format
+}
+
+func (mw *memoryWand) SelectFrame(index int) error {
+	if index < 0 || index >= len(mw.frames) {
+		return fmt.Errorf("frame %d out of range, the image has %d", index, len(mw.frames))
+	}
+	mw.frames, mw.index = mw.frames[index:index+1], 0
+	return nil
+}
+
+// Coalesce does nothing, as frames are drawn in full when read.
+func (mw *memoryWand) Coalesce() error {
+	return nil
+}
+
+// OptimizeLayers does nothing, as frames are always written in full.
+func (mw *memoryWand) OptimizeLayers() error {
+	return nil
+}
+
+// WriteImages writes all images to filename as an animation if it is a GIF. Otherwise, unless
+// there is a single image, each one is written to its own file, numbered like ImageMagick does.
+func (mw *memoryWand) WriteImages(filename string, adjoin bool) error {
+	if len(mw.frames) == 0 {
+		return errNoImage
+	}
+	format := mw.format
+	if filepath.Ext(filename) != "" {
+		format = FormatOf(filename)
+	}
+	if format == "GIF" && adjoin {
+		animation := new(gif.GIF)
+		for _, frame := range mw.frames {
+			paletted := image.NewPaletted(frame.Bounds(), colorpalette.Plan9)
+			draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, image.Point{})
+			animation.Image = append(animation.Image, paletted)
+			animation.Delay = append(animation.Delay, 0)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, animation); err != nil {
+			return err
+		}
+		return os.WriteFile(filename, buf.Bytes(), 0o644)
+	}
+	if len(mw.frames) == 1 {
+		return mw.WriteImage(filename)
+	}
+	extension := filepath.Ext(filename)
+	for n, frame := range mw.frames {
+		data, err := encodeImage(frame, format, mw.quality)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filename, extension), n, extension), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mw *memoryWand) SetResolution(xRes, yRes float64) error {
+	mw.resolution = xRes
+	return nil
+}
+
+func (mw *memoryWand) GetImageResolution() (x, y float64, err error) {
+	if mw.resolution > 0 {
+		return mw.resolution, mw.resolution, nil
+	}
+	return memoryResolution, memoryResolution, nil
+}
+
+func (mw *memoryWand) PingImage(filename string) error {
+	return mw.ReadImage(filename)
+}
+
+func (mw *memoryWand) PingImageBlob(blob []byte) error {
+	return mw.ReadImageBlob(blob)
+}
+
+func (mw *memoryWand) SetBackground(color string) error {
+	_, err := parseColor(color)
+	return err
+}
+
+func (mw *memoryWand) AppendAll(topToBottom bool) error {
+	if len(mw.frames) == 0 {
+		return errNoImage
+	}
+	var width, height int
+	for _, frame := range mw.frames {
+		if topToBottom {
+			width, height = max(width, frame.Bounds().Dx()), height+frame.Bounds().Dy()
+		} else {
+			width, height = width+frame.Bounds().Dx(), max(height, frame.Bounds().Dy())
+		}
+	}
+	appended := image.NewNRGBA(image.Rect(0, 0, width, height))
+	offset := image.Point{}
+	for _, frame := range mw.frames {
+		draw.Draw(appended, frame.Bounds().Add(offset), frame, image.Point{}, draw.Src)
+		if topToBottom {
+			offset.Y += frame.Bounds().Dy()
+		} else {
+			offset.X += frame.Bounds().Dx()
+		}
+	}
+	mw.frames, mw.index = []*image.NRGBA{appended}, 0
+	return nil
+}
+
+// TrimBorders crops the current image to the pixels that differ from color, or from the
+// top-left pixel if color is empty, by more than fuzz, a fraction of the color range.
+func (mw *memoryWand) TrimBorders(color string, fuzz float64) (string, error) {
+	img := mw.current()
+	if img == nil {
+		return "", errNoImage
+	}
+	border := img.NRGBAAt(0, 0)
+	if color != "" {
+		var err error
+		if border, err = parseColor(color); err != nil {
+			return "", err
+		}
+	} else {
+		color = fmt.Sprintf("#%02x%02x%02x%02x", border.R, border.G, border.B, border.A)
+	}
+	box := image.Rectangle{}
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if !similarColors(img.NRGBAAt(x, y), border, fuzz) {
+				box = box.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	// Like ImageMagick, an image made of the border color only is left with a single pixel.
+	if box.Empty() {
+		box = image.Rect(0, 0, 1, 1)
+	}
+	mw.replace(crop(img, box))
+	return color, nil
+}
+
+// AutoOrientImage does nothing but check there is an image, as EXIF orientations are not read.
+func (mw *memoryWand) AutoOrientImage() error {
+	if mw.current() == nil {
+		return errNoImage
+	}
+	return nil
+}
+
+func (mw *memoryWand) FlipImage() error {
+	return mw.transform(func(x, y, width, height int) (int, int) { return x, height - 1 - y }, false)
+}
+
+func (mw *memoryWand) FlopImage() error {
+	return mw.transform(func(x, y, width, height int) (int, int) { return width - 1 - x, y }, false)
+}
+
+// Rotate rotates the current image clockwise by a multiple of 90 degrees; other angles are
+// not supported.
+func (mw *memoryWand) Rotate(degrees float64, background string) error {
+	switch math.Mod(math.Mod(degrees, 360)+360, 360) {
+	case 0:
+		return nil
+	case 90:
+		return mw.transform(func(x, y, width, height int) (int, int) { return y, height - 1 - x }, true)
+	case 180:
+		return mw.transform(func(x, y, width, height int) (int, int) { return width - 1 - x, height - 1 - y }, false)
+	case 270:
+		return mw.transform(func(x, y, width, height int) (int, int) { return width - 1 - y, x }, true)
+	}
+	return fmt.Errorf("rotating by %g degrees is not supported in memory, only multiples of 90", degrees)
+}
+
+// transform replaces the current image with one whose pixel at x, y is the pixel of the
+// current image at the position returned by source, given the current image's dimensions.
+// If transpose is set, the dimensions of the new image are swapped.
+func (mw *memoryWand) transform(source func(x, y, width, height int) (int, int), transpose bool) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	bounds := img.Bounds()
+	if transpose {
+		bounds = image.Rect(0, 0, height, width)
+	}
+	transformed := image.NewNRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			transformed.SetNRGBA(x, y, img.NRGBAAt(source(x, y, width, height)))
+		}
+	}
+	mw.replace(transformed)
+	return nil
+}
+
+func (mw *memoryWand) CloneWand() Wand {
+	clone := *mw
+	clone.frames = make([]*image.NRGBA, len(mw.frames))
+	for n, frame := range mw.frames {
+		clone.frames[n] = crop(frame, frame.Bounds())
+	}
+	clone.options = make(map[string]string, len(mw.options))
+	for key, value := range mw.options {
+		clone.options[key] = value
+	}
+	return &clone
+}
+
+func (mw *memoryWand) CloneImage() Wand {
+	clone := *mw
+	clone.frames, clone.index = nil, 0
+	if img := mw.current(); img != nil {
+		clone.frames = []*image.NRGBA{crop(img, img.Bounds())}
+	}
+	clone.options = make(map[string]string, len(mw.options))
+	for key, value := range mw.options {
+		clone.options[key] = value
+	}
+	return &clone
+}
+
+func (mw *memoryWand) CropImage(width, height uint, x, y int) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	box := image.Rect(x, y, x+int(width), y+int(height)).Intersect(img.Bounds())
+	if box.Empty() {
+		return fmt.Errorf("crop %dx%d+%d+%d is outside of the image", width, height, x, y)
+	}
+	mw.replace(crop(img, box))
+	return nil
+}
+
+// ResetImagePage does nothing, as images held in memory have no virtual canvas.
+func (mw *memoryWand) ResetImagePage(page string) error {
+	return nil
+}
+
+func (mw *memoryWand) Extend(width, height uint, x, y int, background string) error {
+	img := mw.current()
+	if img == nil {
+		return errNoImage
+	}
+	fill, err := parseColor(background)
+	if err != nil {
+		return err
+	}
+	extended := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	draw.Draw(extended, extended.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+	draw.Draw(extended, img.Bounds().Add(image.Pt(x, y)), img, image.Point{}, draw.Src)
+	mw.replace(extended)
+	return nil
+}
+
+// GaussianBlurImage does nothing, as blurring is not rendered in memory.
+func (mw *memoryWand) GaussianBlurImage(radius, sigma float64) error {
+	return nil
+}
+
+// UnsharpMaskImage does nothing, as sharpening is not rendered in memory.
+func (mw *memoryWand) UnsharpMaskImage(radius, sigma, amount, threshold float64) error {
+	return nil
+}
+
+func (mw *memoryWand) GetImageAlphaChannel() bool {
+	img := mw.current()
+	if img == nil {
+		return false
+	}
+	for n := 3; n < len(img.Pix); n += 4 {
+		if img.Pix[n] < 255 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetImageAlphaChannel does nothing, as images held in memory always have an alpha channel.
+func (mw *memoryWand) SetImageAlphaChannel(operation AlphaChannelType) error {
+	return nil
+}
+
+func (mw *memoryWand) SetImageChannelMask(channel ChannelType) ChannelType {
+	previous := mw.channelMask
+	mw.channelMask = channel
+	return previous
+}
+
+// EvaluateImage does nothing, as arithmetic on channels is not rendered in memory.
+func (mw *memoryWand) EvaluateImage(op EvaluateOperator, value float64) error {
+	return nil
+}
+
+// Composite draws the source image over the current image at x, y, whatever the operator.
+func (mw *memoryWand) Composite(source Wand, compose CompositeOperator, x, y int) error {
+	src, ok := source.(*memoryWand)
+	if !ok {
+		return fmt.Errorf("unsupported source %T", source)
+	}
+	img, srcImg := mw.current(), src.current()
+	if img == nil || srcImg == nil {
+		return errNoImage
+	}
+	draw.Draw(img, srcImg.Bounds().Add(image.Pt(x, y)), srcImg, image.Point{}, draw.Over)
+	return nil
+}
+
+// TextMetrics approximates the dimensions of text, as fonts are not read in memory.
+func (mw *memoryWand) TextMetrics(style TextStyle, text string) (TextMetrics, error) {
+	return TextMetrics{
+		Width:    0.6 * style.FontSize * float64(len([]rune(text))),
+		Height:   1.2 * style.FontSize,
+		Ascender: 0.9 * style.FontSize,
+	}, nil
+}
+
+// Annotate does nothing, as text is not rendered in memory.
+func (mw *memoryWand) Annotate(style TextStyle, x, y float64, text string) error {
+	return nil
+}
+
+// Distortion supports the absolute error, the root mean squared error, the peak signal-to-noise
+// ratio and the structural dissimilarity, the latter computed over the whole image at once.
+func (mw *memoryWand) Distortion(reference Wand, metric MetricType) (float64, error) {
+	img, ref, err := mw.comparable(reference)
+	if err != nil {
+		return 0, err
+	}
+	switch metric {
+	case MetricAbsoluteError:
+		differing := 0
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if !similarColors(img.NRGBAAt(x, y), ref.NRGBAAt(x, y), mw.fuzz) {
+					differing++
+				}
+			}
+		}
+		return float64(differing), nil
+	case MetricRootMeanSquaredError:
+		return rootMeanSquaredError(img, ref), nil
+	case MetricPeakSignalToNoiseRatio:
+		return 20 * math.Log10(1/rootMeanSquaredError(img, ref)), nil
+	case MetricStructuralDissimilarity:
+		return (1 - globalSSIM(img, ref)) / 2, nil
+	}
+	return 0, fmt.Errorf("metric %d is not supported in memory", metric)
+}
+
+// comparable returns the current images of the wand and the reference, which must have the
+// same dimensions.
+func (mw *memoryWand) comparable(reference Wand) (*image.NRGBA, *image.NRGBA, error) {
+	ref, ok := reference.(*memoryWand)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported reference %T", reference)
+	}
+	img, refImg := mw.current(), ref.current()
+	if img == nil || refImg == nil {
+		return nil, nil, errNoImage
+	}
+	if img.Bounds() != refImg.Bounds() {
+		return nil, nil, fmt.Errorf("image widths or heights differ")
+	}
+	return img, refImg, nil
+}
+
+func (mw *memoryWand) ExportRGBA() ([]byte, error) {
+	img := mw.current()
+	if img == nil {
+		return nil, errNoImage
+	}
+	return append([]byte(nil), img.Pix...), nil
+}
+
+// Histogram returns the distinct colors of the current image, from the most to the least common.
+func (mw *memoryWand) Histogram() []HistogramColor {
+	img := mw.current()
+	if img == nil {
+		return nil
+	}
+	counts := make(map[[4]uint8]uint)
+	for n := 0; n < len(img.Pix); n += 4 {
+		counts[[4]uint8(img.Pix[n:n+4])]++
+	}
+	histogram := make([]HistogramColor, 0, len(counts))
+	for rgba, count := range counts {
+		histogram = append(histogram, HistogramColor{RGBA: rgba, Count: count})
+	}
+	sort.Slice(histogram, func(a, b int) bool {
+		if histogram[a].Count != histogram[b].Count {
+			return histogram[a].Count > histogram[b].Count
+		}
+		return bytes.Compare(histogram[a].RGBA[:], histogram[b].RGBA[:]) < 0
+	})
+	return histogram
+}
+
+func (mw *memoryWand) SetFuzz(percent float64) error {
+	mw.fuzz = percent / 100
+	return nil
+}
+
+// DiffImage returns an image in which the pixels differing from the reference are red, and
+// the others are a faded copy of the current image.
+func (mw *memoryWand) DiffImage(reference Wand) (Wand, error) {
+	img, ref, err := mw.comparable(reference)
+	if err != nil {
+		return nil, err
+	}
+	diff := image.NewNRGBA(img.Bounds())
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			c := img.NRGBAAt(x, y)
+			if similarColors(c, ref.NRGBAAt(x, y), mw.fuzz) {
+				c = color.NRGBA{R: 255 - (255-c.R)/5, G: 255 - (255-c.G)/5, B: 255 - (255-c.B)/5, A: 255}
+			} else {
+				c = color.NRGBA{R: 241, G: 0, B: 30, A: 255}
+			}
+			diff.SetNRGBA(x, y, c)
+		}
+	}
+	return &memoryWand{frames: []*image.NRGBA{diff}, format: "PNG", options: make(map[string]string)}, nil
+}
+
+// decodeFrames decodes a PNG, JPEG or GIF image, returning its frames along with its format.
+// The frames of animated GIFs are drawn in full, as if coalesced.
+func decodeFrames(data []byte) ([]*image.NRGBA, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "decoding image in memory")
+	}
+	if err := checkPixels(config.Width, config.Height, 1); err != nil {
+		return nil, "", err
+	}
+	format = strings.ToUpper(format)
+	if format == "GIF" {
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "decoding image in memory")
+		}
+		if err := checkPixels(animation.Config.Width, animation.Config.Height, len(animation.Image)); err != nil {
+			return nil, "", err
+		}
+		canvas := image.NewNRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+		frames := make([]*image.NRGBA, len(animation.Image))
+		for n, frame := range animation.Image {
+			draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+			frames[n] = crop(canvas, canvas.Bounds())
+		}
+		return frames, format, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "decoding image in memory")
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return []*image.NRGBA{nrgba}, format, nil
+}
+
+// checkPixels returns an error if an image of the given number of frames, each of the given
+// dimensions, has more pixels than the in-memory backend decodes.
+func checkPixels(width, height, frames int) error {
+	if int64(width)*int64(height)*int64(frames) > memoryMaxPixels {
+		return errors.Errorf("decoding image in memory: %d frames of %dx%d exceed %d pixels", frames, width, height, memoryMaxPixels)
+	}
+	return nil
+}
+
+// encodeImage encodes img in the given format, JPEG with the given quality, GIF or PNG,
+// returning an error for any other format.
+func encodeImage(img *image.NRGBA, format string, quality uint) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "JPEG":
+		if quality == 0 {
+			quality = memoryDefaultQuality
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: int(quality)})
+	case "GIF":
+		err = gif.Encode(&buf, img, nil)
+	case "PNG":
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("encoding %s is not supported in memory, only PNG, JPEG and GIF", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// crop returns a copy of the box of img, moved to the origin.
+func crop(img *image.NRGBA, box image.Rectangle) *image.NRGBA {
+	cropped := image.NewNRGBA(image.Rect(0, 0, box.Dx(), box.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, box.Min, draw.Src)
+	return cropped
+}
+
+// similarColors reports whether no channel of a and b differs by more than fuzz, a fraction of
+// the color range.
+func similarColors(a, b color.NRGBA, fuzz float64) bool {
+	tolerance := fuzz * 255
+	for _, d := range []int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B), int(a.A) - int(b.A)} {
+		if math.Abs(float64(d)) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// rootMeanSquaredError returns the root mean squared error between the channels of two images
+// of the same dimensions, as a fraction of the color range.
+func rootMeanSquaredError(a, b *image.NRGBA) float64 {
+	var sum float64
+	for n := range a.Pix {
+		d := (float64(a.Pix[n]) - float64(b.Pix[n])) / 255
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(a.Pix)))
+}
+
+// globalSSIM returns the structural similarity index between the luma of two images of the
+// same dimensions, computed over the whole images as a single window.
+func globalSSIM(a, b *image.NRGBA) float64 {
+	const c1, c2 = (0.01 * 255) * (0.01 * 255), (0.03 * 255) * (0.03 * 255)
+	luma := func(img *image.NRGBA, n int) float64 {
+		return 0.299*float64(img.Pix[n]) + 0.587*float64(img.Pix[n+1]) + 0.114*float64(img.Pix[n+2])
+	}
+	pixels := float64(len(a.Pix) / 4)
+	var meanA, meanB float64
+	for n := 0; n < len(a.Pix); n += 4 {
+		meanA += luma(a, n)
+		meanB += luma(b, n)
+	}
+	meanA, meanB = meanA/pixels, meanB/pixels
+	var varA, varB, covariance float64
+	for n := 0; n < len(a.Pix); n += 4 {
+		da, db := luma(a, n)-meanA, luma(b, n)-meanB
+		varA, varB, covariance = varA+da*da, varB+db*db, covariance+da*db
+	}
+	varA, varB, covariance = varA/pixels, varB/pixels, covariance/pixels
+	return (2*meanA*meanB + c1) * (2*covariance + c2) / ((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+}
+
+// namedColors are the color names the in-memory backend understands, on top of hex triplets.
+var namedColors = map[string]color.NRGBA{
+	"none":        {},
+	"transparent": {},
+	"white":       {R: 255, G: 255, B: 255, A: 255},
+	"black":       {A: 255},
+	"red":         {R: 255, A: 255},
+	"green":       {G: 128, A: 255},
+	"blue":        {B: 255, A: 255},
+	"gray":        {R: 128, G: 128, B: 128, A: 255},
+}
+
+// parseColor parses a color name among namedColors or a hex color, such as "#1e90ff",
+// "#1e90ff80" or "#fff".
+func parseColor(s string) (color.NRGBA, error) {
+	if c, ok := namedColors[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if !strings.HasPrefix(s, "#") || len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package wand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_memoryWand(t *testing.T) {
+	red, green, blue, white := color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255}, color.NRGBA{B: 255, A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	read := func(t *testing.T, img *image.NRGBA) *memoryWand {
+		mw := NewMemory().(*memoryWand)
+		require.NoError(t, mw.ReadImageBlob(encodePNG(t, img)))
+		return mw
+	}
+
+	t.Run("resize", func(t *testing.T) {
+		mw := read(t, quadrants(4, 4))
+		require.Equal(t, "PNG", mw.GetImageFormat())
+		require.NoError(t, mw.ResizeImage(2, 2, Filter(22)))
+		require.Equal(t, []byte{255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 255, 255, 255, 255, 255}, mustExport(t, mw))
+	})
+
+	t.Run("flip, flop and rotate", func(t *testing.T) {
+		mw := read(t, quadrants(2, 2))
+		require.NoError(t, mw.FlipImage())
+		require.Equal(t, []color.NRGBA{blue, white, red, green}, pixels(mw))
+		require.NoError(t, mw.FlopImage())
+		require.Equal(t, []color.NRGBA{white, blue, green, red}, pixels(mw))
+		require.NoError(t, mw.Rotate(90, ""))
+		require.Equal(t, []color.NRGBA{green, white, red, blue}, pixels(mw))
+		require.NoError(t, mw.Rotate(-90, ""))
+		require.Equal(t, []color.NRGBA{white, blue, green, red}, pixels(mw))
+		require.EqualError(t, mw.Rotate(45, ""), "rotating by 45 degrees is not supported in memory, only multiples of 90")
+	})
+
+	t.Run("trim and extend", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 6, 5))
+		for n := range img.Pix {
+			img.Pix[n] = 255
+		}
+		img.SetNRGBA(2, 1, red)
+		img.SetNRGBA(3, 2, blue)
+		mw := read(t, img)
+		border, err := mw.TrimBorders("", 0)
+		require.NoError(t, err)
+		require.Equal(t, "#ffffffff", border)
+		require.Equal(t, []color.NRGBA{red, white, white, blue}, pixels(mw))
+		require.NoError(t, mw.Extend(3, 2, 1, 0, "black"))
+		require.Equal(t, []color.NRGBA{{A: 255}, red, white, {A: 255}, white, blue}, pixels(mw))
+	})
+
+	t.Run("crop and clone", func(t *testing.T) {
+		mw := read(t, quadrants(4, 4))
+		clone := mw.CloneWand()
+		require.NoError(t, mw.CropImage(2, 2, 2, 2))
+		require.Equal(t, []color.NRGBA{white, white, white, white}, pixels(mw))
+		require.Equal(t, uint(4), clone.GetImageWidth(), "clones are not affected")
+		require.EqualError(t, mw.CropImage(2, 2, 5, 5), "crop 2x2+5+5 is outside of the image")
+	})
+
+	t.Run("clone current image", func(t *testing.T) {
+		mw := read(t, quadrants(4, 4))
+		mw.frames = append(mw.frames, quadrants(2, 2))
+		mw.index = 1
+		clone := mw.CloneImage()
+		require.Equal(t, uint(1), clone.GetNumberImages())
+		require.Equal(t, uint(2), clone.GetImageWidth())
+		require.NoError(t, clone.CropImage(1, 1, 0, 0))
+		require.Equal(t, uint(2), mw.GetImageWidth(), "the original is not affected")
+	})
+
+	t.Run("quantize and histogram", func(t *testing.T) {
+		img := quadrants(4, 4)
+		img.SetNRGBA(0, 0, color.NRGBA{R: 250, A: 255})
+		mw := read(t, img)
+		require.Equal(t, uint(5), mw.GetImageColors())
+		require.NoError(t, mw.Quantize(4, false))
+		require.Equal(t, []HistogramColor{
+			{RGBA: [4]uint8{0, 0, 255, 255}, Count: 4},
+			{RGBA: [4]uint8{0, 255, 0, 255}, Count: 4},
+			{RGBA: [4]uint8{255, 0, 0, 255}, Count: 4},
+			{RGBA: [4]uint8{255, 255, 255, 255}, Count: 4},
+		}, mw.Histogram())
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		mw, reference := read(t, quadrants(4, 4)), read(t, quadrants(4, 4))
+		rmse, err := mw.Distortion(reference, MetricRootMeanSquaredError)
+		require.NoError(t, err)
+		require.Zero(t, rmse)
+		psnr, err := mw.Distortion(reference, MetricPeakSignalToNoiseRatio)
+		require.NoError(t, err)
+		require.True(t, math.IsInf(psnr, 1))
+		dssim, err := mw.Distortion(reference, MetricStructuralDissimilarity)
+		require.NoError(t, err)
+		require.InDelta(t, 0, dssim, 1e-12)
+
+		reference.frames[0].SetNRGBA(0, 0, color.NRGBA{R: 245, G: 10, A: 255})
+		differing, err := mw.Distortion(reference, MetricAbsoluteError)
+		require.NoError(t, err)
+		require.Equal(t, float64(1), differing)
+		require.NoError(t, mw.SetFuzz(5))
+		differing, err = mw.Distortion(reference, MetricAbsoluteError)
+		require.NoError(t, err)
+		require.Zero(t, differing, "differences within the fuzz are ignored")
+		rmse, err = mw.Distortion(reference, MetricRootMeanSquaredError)
+		require.NoError(t, err)
+		require.InDelta(t, math.Sqrt(2*10*10/(255.0*255)/64), rmse, 1e-12)
+
+		require.NoError(t, mw.SetFuzz(0))
+		diff, err := mw.DiffImage(reference)
+		require.NoError(t, err)
+		diffPixels := pixels(diff.(*memoryWand))
+		require.Equal(t, color.NRGBA{R: 241, G: 0, B: 30, A: 255}, diffPixels[0])
+		require.Equal(t, white, diffPixels[15])
+
+		require.NoError(t, reference.ResizeImage(2, 2, Filter(1)))
+		_, err = mw.Distortion(reference, MetricAbsoluteError)
+		require.EqualError(t, err, "image widths or heights differ")
+	})
+
+	t.Run("animation", func(t *testing.T) {
+		animation := &gif.GIF{Delay: []int{10, 10}}
+		for _, c := range []color.Color{red, blue} {
+			frame := image.NewPaletted(image.Rect(0, 0, 2, 2), []color.Color{red, blue})
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 2; x++ {
+					frame.Set(x, y, c)
+				}
+			}
+			animation.Image = append(animation.Image, frame)
+		}
+		var buf bytes.Buffer
+		require.NoError(t, gif.EncodeAll(&buf, animation))
+		mw := NewMemory()
+		require.NoError(t, mw.ReadImageBlob(buf.Bytes()))
+		require.Equal(t, uint(2), mw.GetNumberImages())
+		require.Equal(t, "GIF", mw.GetImageFormat())
+		path := filepath.Join(t.TempDir(), "animation.gif")
+		require.NoError(t, mw.WriteImages(path, true))
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		written, err := gif.DecodeAll(f)
+		require.NoError(t, err)
+		require.Len(t, written.Image, 2)
+		require.NoError(t, mw.SelectFrame(1))
+		require.Equal(t, uint(1), mw.GetNumberImages())
+		require.Equal(t, []color.NRGBA{blue, blue, blue, blue}, pixels(mw.(*memoryWand)))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		mw := NewMemory()
+		require.Equal(t, errNoImage, mw.ResizeImage(1, 1, Filter(1)))
+		require.EqualError(t, mw.ReadImageBlob([]byte("not an image")), "decoding image in memory: image: unknown format")
+		require.Error(t, mw.ReadImage(filepath.Join(t.TempDir(), "missing.png")))
+		require.Zero(t, mw.GetImageWidth())
+
+		require.NoError(t, mw.ReadImageBlob(encodePNG(t, quadrants(2, 2))))
+		path := filepath.Join(t.TempDir(), "image.webp")
+		require.EqualError(t, mw.WriteImage(path), "encoding WEBP is not supported in memory, only PNG, JPEG and GIF")
+		require.NoFileExists(t, path)
+	})
+}
+
+func Test_parseColor(t *testing.T) {
+	testCases := []struct {
+		color         string
+		expectedColor color.NRGBA
+		expectedError error
+	}{
+		{color: "none", expectedColor: color.NRGBA{}},
+		{color: "White", expectedColor: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{color: "#1e90ff", expectedColor: color.NRGBA{R: 30, G: 144, B: 255, A: 255}},
+		{color: "#1e90ff80", expectedColor: color.NRGBA{R: 30, G: 144, B: 255, A: 128}},
+		{color: "#f80", expectedColor: color.NRGBA{R: 255, G: 136, A: 255}},
+		{color: "1e90ff", expectedError: errors.New(`invalid color "1e90ff"`)},
+		{color: "#1e90fg", expectedError: errors.New(`invalid color "#1e90fg"`)},
+		{color: "rebeccapurple", expectedError: errors.New(`invalid color "rebeccapurple"`)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.color, func(t *testing.T) {
+			c, err := parseColor(tc.color)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedColor, c)
+			}
+		})
+	}
+}
+
+func FuzzParseColor(f *testing.F) {
+	for _, s := range []string{"#fff", "#336699", "#33669980", "none", "White", "#", "", "#12345", "#ggg", "336699", "#+1234567", "#é"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := parseColor(s)
+		if err != nil {
+			return
+		}
+		hex := fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+		parsed, err := parseColor(hex)
+		require.NoError(t, err)
+		require.Equal(t, c, parsed)
+	})
+}
+
+// quadrants returns an image whose top-left, top-right, bottom-left and bottom-right quarters
+// are red, green, blue and white.
+func quadrants(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	colors := [2][2]color.NRGBA{
+		{{R: 255, A: 255}, {G: 255, A: 255}},
+		{{B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255}},
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, colors[y*2/height][x*2/width])
+		}
+	}
+	return img
+}
+
+// encodePNG returns img encoded as a PNG.
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// mustExport returns the RGBA pixels of the current image of the wand.
+func mustExport(t *testing.T, mw Wand) []byte {
+	rgba, err := mw.ExportRGBA()
+	require.NoError(t, err)
+	return rgba
+}
+
+// pixels returns the pixels of the current image of the wand, row by row.
+func pixels(mw *memoryWand) []color.NRGBA {
+	img := mw.current()
+	var colors []color.NRGBA
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			colors = append(colors, img.NRGBAAt(x, y))
+		}
+	}
+	return colors
+}
+
+func Test_decodeFramesLimit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		seed          string
+		expectedError string
+	}{
+		{
+			name:          "huge dimensions",
+			seed:          "huge_dimensions.png",
+			expectedError: "decoding image in memory: 1 frames of 100000x100000 exceed 16777216 pixels",
+		},
+		{
+			name:          "huge logical screen",
+			seed:          "huge_screen.gif",
+			expectedError: "decoding image in memory: 1 frames of 65535x65535 exceed 16777216 pixels",
+		},
+		{
+			name:          "too many frames",
+			seed:          "many_frames.gif",
+			expectedError: "decoding image in memory: 100 frames of 512x512 exceed 16777216 pixels",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "seeds", tc.seed))
+			require.NoError(t, err)
+			_, _, err = decodeFrames(data)
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package wand defines the interface through which imageresizer drives ImageMagick's
+// MagickWand API, along with the types its methods take, so that it builds without
+// ImageMagick. It also implements the interface in memory, with Go's image packages.
+package wand
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Filter is the filter used to resize images. Its values are those of ImageMagick 7's
+// FilterType, as are those of imageresizer.FilterType.
+type Filter int
+
+// InterlaceType is the interlace scheme images are encoded with.
+type InterlaceType int
+
+const (
+	InterlaceUndefined InterlaceType = iota // The default of the encoder, which is not to interlace.
+	InterlacePlane                          // Plane interlacing: progressive JPEGs and Adam7 interlaced PNGs.
+)
+
+// AlphaChannelType is an operation on the alpha channel of an image.
+type AlphaChannelType int
+
+const (
+	AlphaChannelSet AlphaChannelType = iota // Activate the alpha channel, opaque where the image had none.
+)
+
+// ChannelType is a set of channels of an image that operations are restricted to.
+type ChannelType int
+
+const (
+	ChannelsDefault ChannelType = iota // The channels operations apply to by default.
+	ChannelAlpha                       // The alpha channel only.
+)
+
+// EvaluateOperator is an arithmetic operator applied to the channels of an image.
+type EvaluateOperator int
+
+const (
+	EvaluateMultiply EvaluateOperator = iota // Multiply the channels by a value.
+)
+
+// CompositeOperator is the way an image is drawn over another.
+type CompositeOperator int
+
+const (
+	CompositeOver    CompositeOperator = iota // Draw the source over the destination.
+	CompositeDstOver                          // Draw the source behind the destination.
+)
+
+// MetricType is a metric measuring how much an image differs from a reference image.
+type MetricType int
+
+const (
+	MetricAbsoluteError           MetricType = iota // Number of differing pixels.
+	MetricRootMeanSquaredError                      // Root mean squared error, normalized from 0 to 1.
+	MetricPeakSignalToNoiseRatio                    // Peak signal-to-noise ratio, in decibels.
+	MetricStructuralDissimilarity                   // Structural dissimilarity (DSSIM), from 0 to 1.
+)
+
+// Wand defines an interface for working with ImageMagick's MagickWand API.
+// It abstracts the operations needed for resizing images, allowing for easier testing
+// and potential future extensions or replacements of the underlying ImageMagick library.
+// It is implemented by imageresizer's wrapper of ImageMagick, when the package is built with
+// it, and in memory by the wands NewMemory returns.
+type Wand interface {
+	ReadImage(filename string) error                       // ReadImage loads an image from the specified file.
+	ReadImageBlob(blob []byte) error                       // ReadImageBlob loads an image from an in-memory blob.
+	ResizeImage(cols uint, rows uint, filter Filter) error // ResizeImage resizes the image using the specified dimensions and filter.
+	GetImageWidth() uint                                   // GetImageWidth returns the width of the current image.
+	GetImageHeight() uint                                  // GetImageHeight returns the height of the current image.
+	SetImageCompressionQuality(quality uint) error         // SetImageCompressionQuality sets the compression quality of the image.
+	SetInterlaceScheme(scheme InterlaceType) error         // SetInterlaceScheme sets the interlace scheme used by the encoder.
+	SetOption(key, value string) error                     // SetOption sets an encoder or decoder option (define).
+	Quantize(colors uint, dither bool) error               // Quantize reduces the image to a palette of at most the given number of colors.
+	SetImageFormat(format string) error                    // SetImageFormat sets the format the image is encoded in.
+	GetImageBlob() ([]byte, error)                         // GetImageBlob returns the image encoded in memory.
+	StripImage() error                                     // StripImage removes profiles and comments from the image.
+	GetImageColors() uint                                  // GetImageColors returns the number of unique colors in the image.
+	GetImageCompressionQuality() uint                      // GetImageCompressionQuality returns the compression quality of the image, as read.
+	GetImageColorspace() string                            // GetImageColorspace returns the name of the colorspace of the image, e.g. "sRGB".
+	GetImageDepth() uint                                   // GetImageDepth returns the number of bits per channel of the image.
+	GetImageOrientation() int                              // GetImageOrientation returns the EXIF orientation of the image, zero when unknown.
+	WriteImage(filename string) error                      // WriteImage writes the image to the specified file.
+	Destroy()                                              // Destroy releases resources associated with the MagickWand.
+
+	// Multi-frame operations.
+	Clear()                                         // Clear removes all images from the wand.
+	GetNumberImages() uint                          // GetNumberImages returns the number of images (frames) in the wand.
+	SetIteratorIndex(index int) bool                // SetIteratorIndex makes the image at index the current one.
+	GetImageFormat() string                         // GetImageFormat returns the format of the current image.
+	SelectFrame(index int) error                    // SelectFrame keeps only the frame at index, coalesced into a full picture.
+	Coalesce() error                                // Coalesce turns every frame into a full picture, as displayed at that point of the animation.
+	OptimizeLayers() error                          // OptimizeLayers reduces every frame to the area that differs from the previous one.
+	WriteImages(filename string, adjoin bool) error // WriteImages writes all images to the specified file.
+	SetResolution(xRes, yRes float64) error         // SetResolution sets the resolution used to read vector images.
+	GetImageResolution() (x, y float64, err error)  // GetImageResolution returns the resolution of the current image.
+	PingImage(filename string) error                // PingImage reads the image attributes, without its pixels.
+	PingImageBlob(blob []byte) error                // PingImageBlob reads the attributes of an in-memory image, without its pixels.
+	SetBackground(color string) error               // SetBackground sets the background color used to read vector images.
+	AppendAll(topToBottom bool) error               // AppendAll replaces all images by a single one in which they are appended.
+
+	// Pre-processing operations.
+	TrimBorders(color string, fuzz float64) (string, error) // TrimBorders removes the image borders matching color, returning the color removed.
+	AutoOrientImage() error                                 // AutoOrientImage rotates the image as its EXIF orientation tells, which it resets.
+	FlipImage() error                                       // FlipImage mirrors the image vertically.
+	FlopImage() error                                       // FlopImage mirrors the image horizontally.
+	Rotate(degrees float64, background string) error        // Rotate rotates the image clockwise, filling the uncovered corners with background.
+
+	// Post-processing operations.
+	CloneWand() Wand                                                  // CloneWand returns a copy of the wand and its images.
+	CloneImage() Wand                                                 // CloneImage returns a new wand holding a copy of the current image only.
+	CropImage(width, height uint, x, y int) error                     // CropImage extracts a region of the image.
+	ResetImagePage(page string) error                                 // ResetImagePage resets the page geometry (virtual canvas) of the image.
+	Extend(width, height uint, x, y int, background string) error     // Extend enlarges the canvas to width x height, placing the image at x, y over the background color.
+	GaussianBlurImage(radius, sigma float64) error                    // GaussianBlurImage blurs the image with a Gaussian operator.
+	UnsharpMaskImage(radius, sigma, amount, threshold float64) error  // UnsharpMaskImage sharpens the image with an unsharp mask.
+	GetImageAlphaChannel() bool                                       // GetImageAlphaChannel reports whether the image has an alpha channel.
+	SetImageAlphaChannel(operation AlphaChannelType) error            // SetImageAlphaChannel activates, deactivates, resets, or sets the alpha channel.
+	SetImageChannelMask(channel ChannelType) ChannelType              // SetImageChannelMask restricts the following operations to the given channels, returning the previous mask.
+	EvaluateImage(op EvaluateOperator, value float64) error           // EvaluateImage applies an arithmetic operator to the image channels.
+	Composite(source Wand, compose CompositeOperator, x, y int) error // Composite draws the source wand's image over the image at the given position.
+	TextMetrics(style TextStyle, text string) (TextMetrics, error)    // TextMetrics measures the given text as it would be drawn with style.
+	Annotate(style TextStyle, x, y float64, text string) error        // Annotate draws the text with its baseline starting at the given position.
+
+	// Analysis operations.
+	Distortion(reference Wand, metric MetricType) (float64, error) // Distortion measures how much the image differs from the reference wand's image.
+	ExportRGBA() ([]byte, error)                                   // ExportRGBA returns the 8-bit RGBA pixels of the image, row by row.
+	Histogram() []HistogramColor                                   // Histogram returns the distinct colors of the image, with their pixel counts.
+	SetFuzz(percent float64) error                                 // SetFuzz sets the percentage of the color range within which colors compare as equal.
+	DiffImage(reference Wand) (Wand, error)                        // DiffImage returns an image highlighting the pixels differing from the reference wand's image.
+}
+
+// TextStyle holds the resolved drawing settings used to render a line of text.
+type TextStyle struct {
+	Font            string  // Path of the font file; empty for the default font.
+	FontSize        float64 // Font size, in points; zero for the default size.
+	Color           string  // Color of the text.
+	StrokeColor     string  // Color of the text outline; empty for none.
+	StrokeWidth     float64 // Width of the text outline.
+	BackgroundColor string  // Color of the box drawn behind the text; empty for no box.
+}
+
+// TextMetrics holds the dimensions of a line of text, in pixels.
+type TextMetrics struct {
+	Width    float64 // Advance width of the text.
+	Height   float64 // Height of the text, from the top of the ascenders to the bottom of the descenders.
+	Ascender float64 // Distance from the top of the text to its baseline.
+}
+
+// HistogramColor is a distinct color of an image, along with the number of pixels having it.
+type HistogramColor struct {
+	RGBA  [4]uint8 // Color, with its opacity.
+	Count uint     // Number of pixels with the color.
+}
+
+// FormatOf returns the format in which ImageMagick writes the given file, which follows its extension.
+func FormatOf(filePath string) string {
+	format := strings.ToUpper(strings.TrimPrefix(filepath.Ext(filePath), "."))
+	if format == "JPG" {
+		return "JPEG"
+	}
+	return format
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultLQIPSize is the longest side, in pixels, of an LQIP when none is set.
+	defaultLQIPSize = 20
+	// defaultLQIPFormat is the format of an LQIP when none is set.
+	defaultLQIPFormat = "WEBP"
+	// defaultLQIPQuality is the compression quality of an LQIP when none is set.
+	defaultLQIPQuality = 30
+	// defaultLQIPBlur is the standard deviation, in pixels, of the blur applied to an LQIP when none is set.
+	defaultLQIPBlur = 1
+)
+
+// lqip returns a low-quality image placeholder of the current image of the wand as a data URI:
+// a tiny, blurred and heavily compressed copy, small enough to be inlined into HTML.
+func lqip(mw magickWand, options PlaceholderOptions, filter FilterType) (string, error) {
+	tiny := mw.CloneImage()
+	defer tiny.Destroy()
+	width, height := int(tiny.GetImageWidth()), int(tiny.GetImageHeight())
+	if width > options.LQIPSize || height > options.LQIPSize {
+		width, height = fitDimensions(width, height, options.LQIPSize, options.LQIPSize)
+		if err := tiny.ResizeImage(uint(width), uint(height), filter.wandFilter()); err != nil {
+			return "", errors.Wrapf(err, "scaling image down to %dx%d", width, height)
+		}
+	}
+	if err := tiny.GaussianBlurImage(0, options.LQIPBlur); err != nil {
+		return "", errors.Wrap(err, "blurring image")
+	}
+	if err := tiny.StripImage(); err != nil {
+		return "", errors.Wrap(err, "stripping metadata")
+	}
+	format := FormatOf("." + options.LQIPFormat)
+	if err := tiny.SetImageFormat(format); err != nil {
+		return "", errors.Wrapf(err, "setting image format to %s", format)
+	}
+	if err := tiny.SetImageCompressionQuality(uint(options.LQIPQuality)); err != nil {
+		return "", errors.Wrapf(err, "setting image compression quality to %d", options.LQIPQuality)
+	}
+	blob, err := tiny.GetImageBlob()
+	if err != nil {
+		return "", errors.Wrap(err, "encoding image")
+	}
+	return "data:image/" + strings.ToLower(format) + ";base64," + base64.StdEncoding.EncodeToString(blob), nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lqip(t *testing.T) {
+	testCases := []struct {
+		name           string
+		options        PlaceholderOptions
+		mockClosure    func(m *mockMagickWand)
+		expectedURI    string
+		expectedWidth  uint
+		expectedHeight uint
+		expectedFormat string
+		expectedError  error
+	}{
+		{
+			name:           "webp by default",
+			expectedURI:    "data:image/webp;base64," + base64.StdEncoding.EncodeToString(make([]byte, 20*14)),
+			expectedWidth:  20,
+			expectedHeight: 14,
+			expectedFormat: "WEBP",
+		},
+		{
+			name:           "jpeg with size and quality",
+			options:        PlaceholderOptions{LQIPSize: 32, LQIPFormat: "jpg", LQIPQuality: 10},
+			expectedURI:    "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(make([]byte, 32*23)),
+			expectedWidth:  32,
+			expectedHeight: 23,
+			expectedFormat: "JPEG",
+		},
+		{
+			name: "error when scaling image down",
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("scaling image down to 20x14: resize image error"),
+		},
+		{
+			name: "error when blurring image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errGaussianBlurImage = errors.New("gaussian blur image error")
+			},
+			expectedError: errors.New("blurring image: gaussian blur image error"),
+		},
+		{
+			name: "error when stripping metadata",
+			mockClosure: func(m *mockMagickWand) {
+				m.errStripImage = errors.New("strip image error")
+			},
+			expectedError: errors.New("stripping metadata: strip image error"),
+		},
+		{
+			name: "error when setting image format",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetImageFormat = errors.New("set image format error")
+			},
+			expectedError: errors.New("setting image format to WEBP: set image format error"),
+		},
+		{
+			name: "error when setting compression quality",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetImageCompressionQuality = errors.New("set image compression quality error")
+			},
+			expectedError: errors.New("setting image compression quality to 30: set image compression quality error"),
+		},
+		{
+			name: "error when encoding image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			expectedError: errors.New("encoding image: get image blob error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{width: 1200, height: 850}
+			m.blobSize = func(quality, width, height uint) int { return int(width * height) }
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			uri, err := lqip(m, tc.options.withDefaults(), FILTER_LANCZOS)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedURI, uri)
+				require.Equal(t, 1, m.imageClones, "only the current image is copied")
+				require.Equal(t, tc.expectedWidth, m.width)
+				require.Equal(t, tc.expectedHeight, m.height)
+				require.Equal(t, tc.expectedFormat, m.imageFormat)
+				require.Equal(t, uint(tc.options.withDefaults().LQIPQuality), m.quality)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMemoryBackend(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "someImage.png")
+	require.NoError(t, os.WriteFile(input, encodePNG(t, quadrants(40, 20)), 0o644))
+	testCases := []struct {
+		name           string
+		options        []Option
+		expectedPath   string
+		expectedWidth  int
+		expectedHeight int
+		expectedFormat string
+	}{
+		{
+			name:           "resize",
+			options:        []Option{WithDimensions(20, 10)},
+			expectedPath:   "someImage_resized.png",
+			expectedWidth:  20,
+			expectedHeight: 10,
+			expectedFormat: "png",
+		},
+		{
+			name:           "rotate and convert",
+			options:        []Option{WithDimensions(10, 20), WithRotate(90, ""), WithOutputFormat("jpg")},
+			expectedPath:   "someImage_resized.jpg",
+			expectedWidth:  10,
+			expectedHeight: 20,
+			expectedFormat: "jpeg",
+		},
+		{
+			name:           "pad",
+			options:        []Option{WithDimensions(30, 30), WithPad(Padding{Color: "#336699"})},
+			expectedPath:   "someImage_resized.png",
+			expectedWidth:  30,
+			expectedHeight: 30,
+			expectedFormat: "png",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			ir := New(append(tc.options, WithOutputDir(outputDir), WithMemoryBackend())...)
+			defer ir.Destroy()
+			path, err := ir.Resize(input)
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(outputDir, tc.expectedPath), path)
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close()
+			config, format, err := image.DecodeConfig(f)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFormat, format)
+			require.Equal(t, tc.expectedWidth, config.Width)
+			require.Equal(t, tc.expectedHeight, config.Height)
+		})
+	}
+}
+
+// quadrants returns an image whose top-left, top-right, bottom-left and bottom-right quarters
+// are red, green, blue and white.
+func quadrants(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	colors := [2][2]color.NRGBA{
+		{{R: 255, A: 255}, {G: 255, A: 255}},
+		{{B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255}},
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, colors[y*2/height][x*2/width])
+		}
+	}
+	return img
+}
+
+// encodePNG returns img encoded as a PNG.
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build !cgo || nomagick
+
+package imageresizer
+
+import "github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
+
+// defaultBackend returns the function creating the wands of resizers that do not process
+// images in memory. The package is built without ImageMagick, with cgo disabled or the
+// nomagick tag, so their wands fail to read any image with ErrNoImageMagick, rather than
+// silently processing it in memory with a lower fidelity.
+func defaultBackend() func() magickWand {
+	return func() magickWand {
+		return unreadableWand{Wand: wand.NewMemory(), err: ErrNoImageMagick}
+	}
+}
+
+// Terminate does nothing, as the package is built without ImageMagick, with cgo disabled or
+// the nomagick tag. With ImageMagick, it releases the resources of its environment.
+func Terminate() {}
+
+// SetSecurityPolicy returns ErrNoImageMagick, as the package is built without ImageMagick,
+// with cgo disabled or the nomagick tag, so that no policy can be mistaken for enforced. The
+// in-memory backend of WithMemoryBackend needs none: it only decodes PNG, JPEG and GIF, never
+// reads other files, and refuses images of more than 16 million pixels.
+func SetSecurityPolicy(policy string) error {
+	return ErrNoImageMagick
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build !cgo || nomagick
+
+package imageresizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// withImageMagick tells the tests shared by both builds that the package is built without
+// ImageMagick.
+const withImageMagick = false
+
+func TestNoImageMagick(t *testing.T) {
+	imageFilePath := filepath.Join("testdata", "fixtures", "pattern.png")
+	testCases := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "Resize",
+			call: func() error {
+				ir := New(WithDimensions(8, 8), WithOutputDir(t.TempDir()))
+				defer ir.Destroy()
+				_, err := ir.Resize(imageFilePath)
+				return err
+			},
+		},
+		{
+			name: "Inspect",
+			call: func() error {
+				_, err := Inspect(imageFilePath)
+				return err
+			},
+		},
+		{
+			name: "InspectReader",
+			call: func() error {
+				file, err := os.Open(imageFilePath)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				_, err = InspectReader(file)
+				return err
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			require.True(t, errors.Is(err, ErrNoImageMagick), "expected ErrNoImageMagick, got %v", err)
+		})
+	}
+	t.Run("SetSecurityPolicy", func(t *testing.T) {
+		require.Equal(t, ErrNoImageMagick, SetSecurityPolicy(UntrustedInputPolicy))
+	})
+	t.Run("memory backend", func(t *testing.T) {
+		ir := New(WithDimensions(8, 8), WithOutputDir(t.TempDir()), WithMemoryBackend())
+		defer ir.Destroy()
+		_, err := ir.Resize(imageFilePath)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOutputNotSmaller is returned in optimize-only mode when the optimized output would not be
+// smaller than the input, in which case it is not written.
+var ErrOutputNotSmaller = errors.New("optimized output is not smaller than the input")
+
+// optimize shrinks the current image without resizing it, unless dimensions are set: it strips
+// its metadata and re-encodes it with the settings that produce the smallest output. PNGs are
+// compressed at the highest level with adaptive filtering, unless set otherwise by
+// WithEncoderOptions; ImageMagick's PNG encoder stores images of at most 256 colors with a
+// palette on its own, which loses nothing, unlike quantizing them, which may merge colors. ImageMagick cannot recompress
+// JPEGs losslessly, so they are re-encoded at the quality they were written with, unless set by
+// WithCompressionQuality, with optimized Huffman tables. The output is written only if it is
+// smaller than the input.
+func (i *imageResizer) optimize(imageFilePath, optimizedImageFilePath string) (Result, error) {
+	input, err := os.Stat(imageFilePath)
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "reading size of %s", imageFilePath)
+	}
+	format := i.outputFormatOf(optimizedImageFilePath)
+	inputQuality := int(i.mw.GetImageCompressionQuality())
+	if err := i.resizeFrame(format); err != nil {
+		return Result{}, err
+	}
+	if err := i.mw.StripImage(); err != nil {
+		return Result{}, errors.Wrap(err, "stripping metadata")
+	}
+	quality, err := i.setOptimizedEncoding(format, inputQuality)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := i.mw.SetImageFormat(format); err != nil {
+		return Result{}, errors.Wrapf(err, "setting image format to %s", format)
+	}
+	blob, err := i.mw.GetImageBlob()
+	if err != nil {
+		return Result{}, errors.Wrap(err, "encoding image")
+	}
+	result := Result{
+		Path:        optimizedImageFilePath,
+		Quality:     quality,
+		InputBytes:  input.Size(),
+		OutputBytes: int64(len(blob)),
+	}
+	if result.OutputBytes >= result.InputBytes {
+		return Result{}, errors.Wrapf(ErrOutputNotSmaller, "%d bytes instead of %d", result.OutputBytes, result.InputBytes)
+	}
+	return result, writeFile(optimizedImageFilePath, blob)
+}
+
+// setOptimizedEncoding configures the encoder of the given format to produce the smallest output
+// without further loss, returning the compression quality used. inputQuality is the quality the
+// image was written with, if known.
+func (i *imageResizer) setOptimizedEncoding(format string, inputQuality int) (int, error) {
+	quality := i.quality(format)
+	switch format {
+	case "JPEG":
+		if quality == 0 && inputQuality > 0 {
+			quality = inputQuality
+			if err := i.mw.SetImageCompressionQuality(uint(quality)); err != nil {
+				return 0, errors.Wrapf(err, "setting image compression quality to %d", quality)
+			}
+		}
+		if err := i.mw.SetOption("jpeg:optimize-coding", "true"); err != nil {
+			return 0, errors.Wrap(err, "enabling optimized coding")
+		}
+	case "PNG":
+		if err := i.setDefaultOption(i.encoderOptions.PNG.CompressionLevel, "png:compression-level", "9"); err != nil {
+			return 0, err
+		}
+		if err := i.setDefaultOption(i.encoderOptions.PNG.Filter, "png:compression-filter", "5"); err != nil {
+			return 0, err
+		}
+	}
+	return quality, nil
+}
+
+// setDefaultOption sets the encoder option key to value, unless it was configured through
+// WithEncoderOptions.
+func (i *imageResizer) setDefaultOption(configured *int, key, value string) error {
+	if configured != nil {
+		return nil
+	}
+	if err := i.mw.SetOption(key, value); err != nil {
+		return errors.Wrapf(err, "setting %s to %s", key, value)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_optimize(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputBytes         int
+		outputFile         string
+		newWidth           *int
+		newHeight          *int
+		compressionQuality int
+		encoderOptions     EncoderOptions
+		mockClosure        func(m *mockMagickWand)
+		expectedResult     Result
+		expectedResizes    int
+		expectedOptions    map[string]string
+		expectedError      error
+	}{
+		{
+			name:            "jpeg keeps its quality",
+			inputBytes:      90000,
+			outputFile:      "someImage_resized.jpg",
+			mockClosure:     func(m *mockMagickWand) { m.inputQuality = 85 },
+			expectedResult:  Result{Quality: 85, InputBytes: 90000, OutputBytes: 85000},
+			expectedOptions: map[string]string{"jpeg:optimize-coding": "true"},
+		},
+		{
+			name:               "jpeg with compression quality",
+			inputBytes:         90000,
+			outputFile:         "someImage_resized.jpg",
+			compressionQuality: 70,
+			mockClosure:        func(m *mockMagickWand) { m.inputQuality = 85 },
+			expectedResult:     Result{Quality: 70, InputBytes: 90000, OutputBytes: 70000},
+			expectedOptions:    map[string]string{"jpeg:optimize-coding": "true"},
+		},
+		{
+			name:               "png with few colors",
+			inputBytes:         90000,
+			outputFile:         "someImage_resized.png",
+			compressionQuality: 10,
+			mockClosure:        func(m *mockMagickWand) { m.colors = 200 },
+			expectedResult:     Result{Quality: 10, InputBytes: 90000, OutputBytes: 10000},
+			expectedOptions: map[string]string{
+				"png:compression-level":  "9",
+				"png:compression-filter": "5",
+			},
+		},
+		{
+			name:               "gif with few colors",
+			inputBytes:         90000,
+			outputFile:         "someImage_resized.gif",
+			compressionQuality: 10,
+			mockClosure:        func(m *mockMagickWand) { m.colors = 16 },
+			expectedResult:     Result{Quality: 10, InputBytes: 90000, OutputBytes: 10000},
+		},
+		{
+			name:               "png with configured filter",
+			inputBytes:         90000,
+			outputFile:         "someImage_resized.png",
+			compressionQuality: 10,
+			encoderOptions:     EncoderOptions{PNG: PNGOptions{Filter: IntPtr(0)}},
+			mockClosure:        func(m *mockMagickWand) { m.colors = 300 },
+			expectedResult:     Result{Quality: 10, InputBytes: 90000, OutputBytes: 10000},
+			expectedOptions: map[string]string{
+				"png:compression-level":  "9",
+				"png:compression-filter": "0",
+			},
+		},
+		{
+			name:               "resizes when dimensions are set",
+			inputBytes:         90000,
+			outputFile:         "someImage_resized.jpg",
+			newWidth:           IntPtr(600),
+			newHeight:          IntPtr(425),
+			compressionQuality: 70,
+			expectedResult:     Result{Quality: 70, InputBytes: 90000, OutputBytes: 70000},
+			expectedResizes:    1,
+			expectedOptions:    map[string]string{"jpeg:optimize-coding": "true"},
+		},
+		{
+			name:          "error when reading input size",
+			outputFile:    "someImage_resized.jpg",
+			expectedError: errors.New("reading size of %s: stat %s: no such file or directory"),
+		},
+		{
+			name:               "error when output is larger than input",
+			inputBytes:         50000,
+			outputFile:         "someImage_resized.jpg",
+			compressionQuality: 70,
+			expectedError:      errors.New("70000 bytes instead of 50000: optimized output is not smaller than the input"),
+		},
+		{
+			name:       "error when stripping metadata",
+			inputBytes: 90000,
+			outputFile: "someImage_resized.jpg",
+			mockClosure: func(m *mockMagickWand) {
+				m.errStripImage = errors.New("strip image error")
+			},
+			expectedError: errors.New("stripping metadata: strip image error"),
+		},
+		{
+			name:       "error when encoding image",
+			inputBytes: 90000,
+			outputFile: "someImage_resized.jpg",
+			mockClosure: func(m *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			expectedError: errors.New("encoding image: get image blob error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "someImage.jpg")
+			if tc.inputBytes > 0 {
+				require.NoError(t, os.WriteFile(input, make([]byte, tc.inputBytes), 0o644))
+			}
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:                 m,
+				newWidth:           tc.newWidth,
+				newHeight:          tc.newHeight,
+				compressionQuality: tc.compressionQuality,
+				encoderOptions:     tc.encoderOptions,
+				optimizeOnly:       true,
+			}
+			output := filepath.Join(dir, tc.outputFile)
+			result, err := ir.optimize(input, output)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				expectedError := tc.expectedError.Error()
+				if tc.inputBytes == 0 {
+					expectedError = fmt.Sprintf(expectedError, input, input)
+				}
+				require.Equal(t, expectedError, err.Error())
+				require.NoFileExists(t, output)
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				tc.expectedResult.Path = output
+				require.Equal(t, tc.expectedResult, result)
+				require.Equal(t, tc.expectedResizes, m.resizes)
+				require.Equal(t, tc.expectedOptions, m.options)
+				require.Zero(t, m.quantizedColors, "quantizing may merge colors")
+				require.Equal(t, []string{"strip"}, m.operations)
+				require.FileExists(t, output)
+			}
+		})
+	}
+}
+
+func TestErrOutputNotSmaller(t *testing.T) {
+	testCases := []struct {
+		name       string
+		inputBytes int
+	}{
+		{
+			name:       "larger output",
+			inputBytes: 10,
+		},
+		{
+			name:       "output of the same size",
+			inputBytes: 50000,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "someImage.png")
+			require.NoError(t, os.WriteFile(input, make([]byte, tc.inputBytes), 0o644))
+			ir := &imageResizer{mw: new(mockMagickWand), compressionQuality: 50, optimizeOnly: true}
+			output := filepath.Join(dir, "someImage_resized.png")
+			_, err := ir.optimize(input, output)
+			require.ErrorIs(t, err, ErrOutputNotSmaller)
+			require.NoFileExists(t, output)
+		})
+	}
+}
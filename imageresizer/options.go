@@ -4,6 +4,8 @@
 
 package imageresizer
 
+import "strings"
+
 // Option is a function that configures an imageResizer.
 // It is used in the functional options pattern for initializing imageResizer instances.
 type Option func(*imageResizer)
@@ -42,3 +44,314 @@ func WithOutputDir(outputDir string) Option {
 		i.outputDir = outputDir // Set the output directory.
 	}
 }
+
+// WithSharpen returns an Option that sets an unsharp mask to be applied after resizing,
+// which restores the crispness that downscaling tends to take away.
+// The radius and sigma, in pixels, define the Gaussian used for the mask (a radius of 0 lets
+// ImageMagick pick one), amount is the fraction of the difference added back into the image,
+// and threshold is the minimum difference, as a fraction of QuantumRange, needed to apply it.
+func WithSharpen(radius, sigma, amount, threshold float64) Option {
+	return func(i *imageResizer) {
+		i.unsharpMask = &unsharpMask{radius, sigma, amount, threshold} // Set the unsharp mask.
+		i.autoSharpen = false
+	}
+}
+
+// WithAutoSharpen returns an Option that applies an unsharp mask after resizing whose
+// parameters are derived from how much the image is reduced. Images that are not
+// reduced are left untouched.
+func WithAutoSharpen() Option {
+	return func(i *imageResizer) {
+		i.unsharpMask = nil
+		i.autoSharpen = true // Derive the unsharp mask from the reduction factor.
+	}
+}
+
+// WithWatermark returns an Option that sets a watermark to be composited over the image
+// after it has been resized. The watermark can be placed once according to its gravity and
+// offsets, or tiled across the whole image, and optionally scaled and made translucent.
+func WithWatermark(watermark Watermark) Option {
+	return func(i *imageResizer) {
+		i.watermark = &watermark // Set the watermark.
+		i.watermarkBlob = nil
+	}
+}
+
+// WithText returns an Option that adds a text overlay, such as a copyright notice or a
+// photographer credit, to be rendered over the image after it has been resized.
+// It can be given several times; overlays are drawn in the order they were added.
+func WithText(overlay TextOverlay) Option {
+	return func(i *imageResizer) {
+		i.textOverlays = append(i.textOverlays, overlay) // Add the text overlay.
+	}
+}
+
+// WithPad returns an Option that letterboxes the image: instead of being stretched to the
+// dimensions set by WithDimensions, it is resized to fit inside them, preserving its aspect
+// ratio, and its canvas is then extended to exactly those dimensions.
+func WithPad(padding Padding) Option {
+	return func(i *imageResizer) {
+		i.padding = &padding // Set the padding.
+	}
+}
+
+// WithTrim returns an Option that removes uniform borders, such as large white margins, from the
+// image before it is resized. The border color is detected from the image corners, and fuzz is the
+// tolerance, from 0 to 1, under which a color is considered the same as the border color.
+// Animated images are not trimmed.
+func WithTrim(fuzz float64) Option {
+	return WithTrimOptions(Trim{Fuzz: fuzz})
+}
+
+// WithTrimOptions returns an Option that removes uniform borders from the image before it is
+// resized, allowing the border color to be given and a uniform padding to be re-added afterward.
+func WithTrimOptions(trim Trim) Option {
+	return func(i *imageResizer) {
+		i.trimming = &trim // Set the border trimming.
+	}
+}
+
+// WithRotate returns an Option that rotates the image clockwise by the given angle, in degrees,
+// before it is resized, so that the dimensions set by WithDimensions refer to the rotated image.
+// Angles that are not multiples of 90 uncover the corners of the canvas, which are filled with
+// the background color, e.g. "white" (the default when empty) or "none" for transparent.
+func WithRotate(degrees float64, background string) Option {
+	return func(i *imageResizer) {
+		i.rotation = &rotation{degrees, background} // Set the rotation.
+	}
+}
+
+// WithFlip returns an Option that mirrors the image vertically (upside down) before it is resized.
+func WithFlip() Option {
+	return func(i *imageResizer) {
+		i.flip = true // Mirror the image vertically.
+	}
+}
+
+// WithFlop returns an Option that mirrors the image horizontally (left to right) before it is resized.
+func WithFlop() Option {
+	return func(i *imageResizer) {
+		i.flop = true // Mirror the image horizontally.
+	}
+}
+
+// WithFrame returns an Option that keeps only the frame at the given index, starting from 0,
+// of multi-frame images such as animated GIFs, producing a still image.
+func WithFrame(index int) Option {
+	return func(i *imageResizer) {
+		i.frame = IntPtr(index) // Set the frame to keep.
+	}
+}
+
+// WithAnimatedWebP returns an Option that converts animated GIFs to animated WebP, which
+// are usually much smaller. The output file gets the ".webp" extension.
+func WithAnimatedWebP() Option {
+	return func(i *imageResizer) {
+		i.animateWebP = true // Convert animated GIFs to animated WebP.
+	}
+}
+
+// WithOutputFormat returns an Option that sets the format of the resized image, such as "png",
+// "jpg" or "webp". It becomes the extension of the output file, from which ImageMagick picks
+// the encoder. If not set, the format of the original image is kept.
+func WithOutputFormat(format string) Option {
+	return func(i *imageResizer) {
+		i.outputFormat = strings.TrimPrefix(format, ".") // Set the output format.
+	}
+}
+
+// WithPages returns an Option that selects the pages, numbered from 1, to be read from multi-page
+// images such as TIFFs and PDFs. Both first and last are included, so a single page is selected
+// by giving the same number twice. If not set, all pages are read.
+func WithPages(first, last int) Option {
+	return func(i *imageResizer) {
+		i.pages = &pageRange{first, last} // Set the pages to read.
+	}
+}
+
+// WithDensity returns an Option that sets the resolution, in DPI, at which vector sources such as
+// PDFs and SVGs are rasterized when read. Higher densities give sharper pages at the cost of memory.
+// If not set, vector sources are rasterized at the density that matches the dimensions set by
+// WithDimensions, so that they are rendered once at full quality instead of scaled as a bitmap.
+func WithDensity(dpi float64) Option {
+	return func(i *imageResizer) {
+		i.density = dpi // Set the density.
+	}
+}
+
+// WithContactStrip returns an Option that appends all the pages of multi-page images side by
+// side, after they have been resized, producing a single image.
+func WithContactStrip() Option {
+	return func(i *imageResizer) {
+		i.contactStrip = true // Append the pages into a single image.
+	}
+}
+
+// WithTransparentBackground returns an Option that rasterizes vector sources, such as SVGs, over a
+// transparent background instead of a white one. The output format must support transparency.
+func WithTransparentBackground() Option {
+	return func(i *imageResizer) {
+		i.transparentBackground = true // Rasterize vector sources over a transparent background.
+	}
+}
+
+// WithProgressiveJPEG returns an Option that writes progressive JPEGs, which browsers can
+// display at low quality before they are fully downloaded. They are usually slightly smaller, too.
+func WithProgressiveJPEG() Option {
+	return func(i *imageResizer) {
+		i.progressiveJPEG = true // Write progressive JPEG outputs.
+	}
+}
+
+// WithInterlacedPNG returns an Option that writes Adam7 interlaced PNGs, which browsers can
+// display at low resolution before they are fully downloaded, at the cost of larger files.
+func WithInterlacedPNG() Option {
+	return func(i *imageResizer) {
+		i.interlacedPNG = true // Write interlaced PNG outputs.
+	}
+}
+
+// WithChromaSubsampling returns an Option that sets the chroma subsampling of JPEG outputs.
+// CHROMA_SUBSAMPLING_420 gives the smallest files, while CHROMA_SUBSAMPLING_444 keeps sharp color
+// edges, such as red text. If not set, the encoder picks one based on the compression quality.
+func WithChromaSubsampling(cs ChromaSubsampling) Option {
+	return func(i *imageResizer) {
+		i.chromaSubsampling = cs // Set the chroma subsampling.
+	}
+}
+
+// WithOptimizedCoding returns an Option that computes optimal Huffman tables for JPEG outputs,
+// which makes them slightly smaller without any loss of quality, at the cost of encoding time.
+func WithOptimizedCoding() Option {
+	return func(i *imageResizer) {
+		i.optimizeCoding = true // Compute optimal Huffman tables.
+	}
+}
+
+// WithEncoderOptions returns an Option that sets format-specific encoder settings, such as WebP
+// lossless mode, AVIF speed, PNG compression level or GIF palette size. Each section is applied
+// only when writing its format.
+func WithEncoderOptions(eo EncoderOptions) Option {
+	return func(i *imageResizer) {
+		i.encoderOptions = eo // Set the format-specific encoder settings.
+	}
+}
+
+// WithMaxOutputBytes returns an Option that limits the size of the output file. The highest
+// compression quality, up to the one set by WithCompressionQuality, whose output fits in maxBytes
+// is searched for by encoding the image in memory. If none fits and allowDownscale is set, the
+// dimensions are reduced step by step until it does; otherwise, resizing fails.
+// It applies to single-frame images only.
+func WithMaxOutputBytes(maxBytes int, allowDownscale bool) Option {
+	return func(i *imageResizer) {
+		i.maxOutputBytes = maxBytes // Set the maximum output size.
+		i.allowDownscale = allowDownscale
+	}
+}
+
+// WithTargetSSIM returns an Option that picks the lowest compression quality, up to the one set
+// by WithCompressionQuality, whose output keeps a structural similarity (SSIM) of at least minSSIM,
+// between 0 and 1, against the resized but unencoded image. The highest quality is used when none
+// reaches it. The SSIM achieved is reported by ResizeWithResult. It applies to single-frame images
+// only; when WithMaxOutputBytes is also given, the size limit takes precedence.
+func WithTargetSSIM(minSSIM float64) Option {
+	return func(i *imageResizer) {
+		i.targetSSIM = minSSIM // Set the minimum SSIM.
+	}
+}
+
+// WithMaxDSSIM returns an Option that works like WithTargetSSIM, with the target expressed as
+// a maximum structural dissimilarity (DSSIM), which is (1 - SSIM) / 2.
+func WithMaxDSSIM(maxDSSIM float64) Option {
+	return func(i *imageResizer) {
+		i.targetSSIM = ssimFromDSSIM(maxDSSIM) // Set the minimum SSIM the DSSIM corresponds to.
+	}
+}
+
+// WithCandidateFormats returns an Option that encodes the resized image to each of the given
+// formats (e.g. "jpeg", "webp", "avif") at equivalent quality, keeping only the smallest output.
+// The image is resized once for all of them. The extension of the output is that of its format,
+// and ResizeWithResult reports the size of every candidate. It applies to single-frame images
+// only, and takes precedence over WithOutputFormat.
+func WithCandidateFormats(formats ...string) Option {
+	return func(i *imageResizer) {
+		i.candidateFormats = formats // Set the candidate formats.
+	}
+}
+
+// WithAllCandidates returns an Option that keeps the output in every format given to
+// WithCandidateFormats, along with a JSON manifest telling which one is the smallest.
+func WithAllCandidates() Option {
+	return func(i *imageResizer) {
+		i.keepAllCandidates = true // Keep every candidate.
+	}
+}
+
+// WithOptimizeOnly returns an Option that shrinks existing files instead of resizing them: the
+// image keeps its dimensions, unless set by WithDimensions, its metadata is stripped, and it is
+// re-encoded with the settings producing the smallest output without further loss. The output
+// is only written when smaller than the input. ResizeWithResult reports both sizes.
+// It applies to single-frame images only.
+func WithOptimizeOnly() Option {
+	return func(i *imageResizer) {
+		i.optimizeOnly = true // Only optimize the file.
+	}
+}
+
+// WithExactDecode returns an Option that decodes JPEGs at full size. By default, when the
+// dimensions are at most half of those of a JPEG, it is scaled down while being decoded,
+// which is much faster, and then resized precisely.
+func WithExactDecode() Option {
+	return func(i *imageResizer) {
+		i.exactDecode = true // Decode JPEGs at full size.
+	}
+}
+
+// WithPlaceholder returns an Option that computes placeholders, such as a BlurHash, a
+// ThumbHash or an LQIP data URI, from a tiny copy of the resized image. They are reported
+// by ResizeWithResult. It applies to single-frame images only.
+func WithPlaceholder(options PlaceholderOptions) Option {
+	return func(i *imageResizer) {
+		i.placeholderOptions = &options // Set the placeholders to compute.
+	}
+}
+
+// WithColors returns an Option that analyzes the colors of the resized image, reporting its
+// average and dominant colors along with a palette of up to paletteSize colors through
+// ResizeWithResult. A paletteSize of zero or less disables the analysis. It applies to
+// single-frame images only.
+func WithColors(paletteSize int) Option {
+	return func(i *imageResizer) {
+		i.paletteSize = max(paletteSize, 0) // Set the number of palette colors.
+	}
+}
+
+// WithHashAlgorithm returns an Option that sets the algorithm PerceptualHash computes hashes
+// with. HASH_DIFFERENCE, the default, is the fastest; HASH_DCT better withstands changes of
+// contrast and color. Hashes computed with different algorithms cannot be compared.
+func WithHashAlgorithm(algorithm HashAlgorithm) Option {
+	return func(i *imageResizer) {
+		i.hashAlgorithm = algorithm // Set the perceptual hash algorithm.
+	}
+}
+
+// WithCompareOptions returns an Option that sets how Compare compares images: the fuzz within
+// which pixels count as equal, and the path of a diff image to write, if any.
+func WithCompareOptions(options CompareOptions) Option {
+	return func(i *imageResizer) {
+		i.compareOptions = options // Set the comparison settings.
+	}
+}
+
+// WithMemoryBackend returns an Option that processes images in memory with Go's image packages
+// instead of ImageMagick, which is then neither initialized nor called. It is meant for tests
+// that must run where ImageMagick is not installed, and only approximates its output: PNG,
+// JPEG and GIF are the only formats decoded and encoded, images of more than 16 million pixels
+// are refused, resizing uses the nearest neighbour whatever the filter, EXIF orientations are
+// ignored, and effects such as blurring, sharpening, opacity and text are not rendered. Builds without ImageMagick, with CGO_ENABLED=0 or the nomagick build tag,
+// need it: their other resizers fail with ErrNoImageMagick.
+func WithMemoryBackend() Option {
+	return func(i *imageResizer) {
+		i.memoryBackend = true // Process images in memory.
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// defaultRotationBackground is the color used to fill the corners uncovered by a rotation
+// when no background is given.
+const defaultRotationBackground = "white"
+
+// rotation holds the settings of the rotation applied before resizing.
+type rotation struct {
+	degrees    float64 // Clockwise angle of the rotation.
+	background string  // Color used to fill the corners uncovered by the rotation.
+}
+
+// orient applies the EXIF orientation of the image, then mirrors and rotates it, in this order,
+// before the target dimensions are computed, so that they refer to the final orientation of
+// the image.
+func (i *imageResizer) orient() error {
+	if orientation := i.mw.GetImageOrientation(); orientation > 1 {
+		// 1 is the top-left orientation, which needs no change, and 0 an unknown one.
+		if err := i.mw.AutoOrientImage(); err != nil {
+			return errors.Wrapf(err, "applying orientation %d", orientation)
+		}
+	}
+	if i.flip {
+		if err := i.mw.FlipImage(); err != nil {
+			return errors.Wrap(err, "flipping image")
+		}
+	}
+	if i.flop {
+		if err := i.mw.FlopImage(); err != nil {
+			return errors.Wrap(err, "flopping image")
+		}
+	}
+	if i.rotation == nil || math.Mod(i.rotation.degrees, 360) == 0 {
+		return nil
+	}
+	background := i.rotation.background
+	if background == "" {
+		background = defaultRotationBackground
+	}
+	if err := i.mw.Rotate(i.rotation.degrees, background); err != nil {
+		return errors.Wrapf(err, "rotating image by %g degrees", i.rotation.degrees)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_orient(t *testing.T) {
+	testCases := []struct {
+		name               string
+		flip               bool
+		flop               bool
+		rotation           *rotation
+		mockClosure        func(m *mockMagickWand)
+		expectedOperations []string
+		expectedError      error
+	}{
+		{
+			name: "nothing to do",
+		},
+		{
+			name:     "full turn is ignored",
+			rotation: &rotation{degrees: -360},
+		},
+		{
+			name:               "mirroring happens before rotating",
+			flip:               true,
+			flop:               true,
+			rotation:           &rotation{degrees: 30, background: "none"},
+			expectedOperations: []string{"flip", "flop", "rotate 30 none"},
+		},
+		{
+			name: "exif orientation is applied before mirroring",
+			flop: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.orientation = 6
+			},
+			expectedOperations: []string{"auto-orient", "flop"},
+		},
+		{
+			name: "top-left orientation needs no change",
+			mockClosure: func(m *mockMagickWand) {
+				m.orientation = 1
+			},
+		},
+		{
+			name:               "default background",
+			rotation:           &rotation{degrees: 45},
+			expectedOperations: []string{"rotate 45 white"},
+		},
+		{
+			name: "error when applying orientation",
+			mockClosure: func(m *mockMagickWand) {
+				m.orientation = 8
+				m.errAutoOrientImage = errors.New("auto orient image error")
+			},
+			expectedError: errors.New("applying orientation 8: auto orient image error"),
+		},
+		{
+			name: "error when flipping image",
+			flip: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errFlipImage = errors.New("flip image error")
+			},
+			expectedError: errors.New("flipping image: flip image error"),
+		},
+		{
+			name: "error when flopping image",
+			flop: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errFlopImage = errors.New("flop image error")
+			},
+			expectedError: errors.New("flopping image: flop image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:       m,
+				flip:     tc.flip,
+				flop:     tc.flop,
+				rotation: tc.rotation,
+			}
+			err := ir.orient()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOperations, m.operations)
+			}
+		})
+	}
+}
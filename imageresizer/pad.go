@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// defaultPaddingColor is the canvas color used when a Padding does not specify one.
+const defaultPaddingColor = "white"
+
+// Padding describes how an image is letterboxed: it is resized to fit inside the target
+// dimensions, preserving its aspect ratio, and its canvas is then extended to exactly
+// the target dimensions.
+type Padding struct {
+	Color   string      // Color of the extended canvas, e.g. "white", "#f0f0f0" or "none" for transparent; defaults to white.
+	Blur    bool        // Whether to fill the extended canvas with a blurred, enlarged copy of the image instead of Color.
+	Gravity GravityType // Where the image sits on the canvas; defaults to GRAVITY_CENTER.
+}
+
+// fitDimensions returns the largest dimensions with the aspect ratio of width x height
+// that fit inside maxWidth x maxHeight.
+func fitDimensions(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	return scaledDimensions(width, height, scale)
+}
+
+// coverDimensions returns the smallest dimensions with the aspect ratio of width x height
+// that cover minWidth x minHeight.
+func coverDimensions(width, height, minWidth, minHeight int) (int, int) {
+	scale := math.Max(float64(minWidth)/float64(width), float64(minHeight)/float64(height))
+	return scaledDimensions(width, height, scale)
+}
+
+// scaledDimensions returns width x height multiplied by scale, never less than one pixel.
+func scaledDimensions(width, height int, scale float64) (int, int) {
+	newWidth := int(math.Round(float64(width) * scale))
+	newHeight := int(math.Round(float64(height) * scale))
+	return max(newWidth, 1), max(newHeight, 1)
+}
+
+// pad extends the canvas of the resized image to the target dimensions, placing the image
+// according to the padding gravity.
+func (i *imageResizer) pad() error {
+	if i.padding == nil {
+		return nil
+	}
+	width, height := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+	canvasWidth, canvasHeight := *i.newWidth, *i.newHeight
+	if width == canvasWidth && height == canvasHeight {
+		return nil
+	}
+	gravity := i.padding.Gravity
+	if gravity == GRAVITY_UNDEFINED {
+		gravity = GRAVITY_CENTER
+	}
+	x, y := gravityPosition(gravity, canvasWidth, canvasHeight, width, height, 0, 0)
+	if !i.padding.Blur {
+		color := i.padding.Color
+		if color == "" {
+			color = defaultPaddingColor
+		}
+		return i.mw.Extend(uint(canvasWidth), uint(canvasHeight), x, y, color)
+	}
+	background, err := i.blurredBackground(canvasWidth, canvasHeight)
+	if err != nil {
+		return errors.Wrap(err, "creating blurred background")
+	}
+	defer background.Destroy()
+	if err := i.mw.Extend(uint(canvasWidth), uint(canvasHeight), x, y, "none"); err != nil {
+		return err
+	}
+	return i.mw.Composite(background, compositeDstOver, 0, 0)
+}
+
+// blurredBackground returns a copy of the resized image enlarged to cover the whole canvas,
+// cropped around its center and blurred, to be placed behind the image.
+func (i *imageResizer) blurredBackground(canvasWidth, canvasHeight int) (magickWand, error) {
+	background := i.mw.CloneImage()
+	width, height := coverDimensions(int(background.GetImageWidth()), int(background.GetImageHeight()), canvasWidth, canvasHeight)
+	if err := background.ResizeImage(uint(width), uint(height), i.filterType.wandFilter()); err != nil {
+		background.Destroy()
+		return nil, errors.Wrap(err, "resizing background")
+	}
+	if err := background.CropImage(uint(canvasWidth), uint(canvasHeight), (width-canvasWidth)/2, (height-canvasHeight)/2); err != nil {
+		background.Destroy()
+		return nil, errors.Wrap(err, "cropping background")
+	}
+	if err := background.ResetImagePage(""); err != nil {
+		background.Destroy()
+		return nil, errors.Wrap(err, "resetting background page")
+	}
+	sigma := math.Max(float64(max(canvasWidth, canvasHeight))/50, 1)
+	if err := background.GaussianBlurImage(0, sigma); err != nil {
+		background.Destroy()
+		return nil, errors.Wrap(err, "blurring background")
+	}
+	return background, nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fitDimensions(t *testing.T) {
+	testCases := []struct {
+		name                          string
+		width, height                 int
+		expectedWidth, expectedHeight int
+	}{
+		{
+			name:           "landscape",
+			width:          1200,
+			height:         850,
+			expectedWidth:  1000,
+			expectedHeight: 708,
+		},
+		{
+			name:           "portrait",
+			width:          600,
+			height:         1200,
+			expectedWidth:  500,
+			expectedHeight: 1000,
+		},
+		{
+			name:           "small image is enlarged",
+			width:          100,
+			height:         50,
+			expectedWidth:  1000,
+			expectedHeight: 500,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			width, height := fitDimensions(tc.width, tc.height, 1000, 1000)
+			require.Equal(t, tc.expectedWidth, width)
+			require.Equal(t, tc.expectedHeight, height)
+		})
+	}
+}
+
+func Test_coverDimensions(t *testing.T) {
+	width, height := coverDimensions(1200, 850, 1000, 1000)
+	require.Equal(t, 1412, width)
+	require.Equal(t, 1000, height)
+}
+
+func Test_pad(t *testing.T) {
+	testCases := []struct {
+		name            string
+		padding         *Padding
+		newWidth        int
+		newHeight       int
+		mockClosure     func(m *mockMagickWand)
+		expectedExtents []extent
+		expectedError   error
+	}{
+		{
+			name:      "no padding",
+			newWidth:  1000,
+			newHeight: 1000,
+		},
+		{
+			name:      "image already has the target dimensions",
+			padding:   &Padding{},
+			newWidth:  1200,
+			newHeight: 850,
+		},
+		{
+			name:            "default color and gravity",
+			padding:         &Padding{},
+			newWidth:        1200,
+			newHeight:       1200,
+			expectedExtents: []extent{{1200, 1200, 0, 175, "white"}},
+		},
+		{
+			name:            "transparent, attached to the top",
+			padding:         &Padding{Color: "none", Gravity: GRAVITY_NORTH},
+			newWidth:        1200,
+			newHeight:       1200,
+			expectedExtents: []extent{{1200, 1200, 0, 0, "none"}},
+		},
+		{
+			name:            "blurred",
+			padding:         &Padding{Blur: true},
+			newWidth:        1200,
+			newHeight:       1200,
+			expectedExtents: []extent{{1200, 1200, 0, 175, "none"}},
+		},
+		{
+			name:      "error when blurring background",
+			padding:   &Padding{Blur: true},
+			newWidth:  1200,
+			newHeight: 1200,
+			mockClosure: func(m *mockMagickWand) {
+				m.errGaussianBlurImage = errors.New("gaussian blur image error")
+			},
+			expectedError: errors.New("creating blurred background: blurring background: gaussian blur image error"),
+		},
+		{
+			name:      "error when compositing blurred background",
+			padding:   &Padding{Blur: true},
+			newWidth:  1200,
+			newHeight: 1200,
+			mockClosure: func(m *mockMagickWand) {
+				m.errComposite = errors.New("composite error")
+			},
+			expectedError: errors.New("composite error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:        m,
+				newWidth:  IntPtr(tc.newWidth),
+				newHeight: IntPtr(tc.newHeight),
+				padding:   tc.padding,
+			}
+			err := ir.pad()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedExtents, m.extents)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// animationFormats lists the formats whose multiple images are the frames of an animation,
+// as opposed to the pages of a document.
+var animationFormats = map[string]bool{
+	"GIF":  true,
+	"WEBP": true,
+	"APNG": true,
+	"MNG":  true,
+}
+
+// multiImageFormats lists the formats a single file of which holds several images, to which
+// the pages of a document can be written together.
+var multiImageFormats = map[string]bool{
+	"TIFF": true,
+	"TIF":  true,
+	"PDF":  true,
+	"PS":   true,
+	"MIFF": true,
+	"GIF":  true,
+	"WEBP": true,
+	"APNG": true,
+	"MNG":  true,
+}
+
+// isAnimationFormat reports whether the multiple images of the given format are animation frames.
+func isAnimationFormat(format string) bool {
+	return animationFormats[strings.ToUpper(format)]
+}
+
+// pageRange is an inclusive range of pages, numbered from 1.
+type pageRange struct {
+	first int // First page of the range.
+	last  int // Last page of the range.
+}
+
+// validate returns an error if the range is not a valid range of pages. A nil range is valid.
+func (p *pageRange) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.first < 1 || p.last < p.first {
+		return fmt.Errorf("invalid page range %d-%d", p.first, p.last)
+	}
+	return nil
+}
+
+// selector returns the suffix that makes ImageMagick read only the pages in the range,
+// which avoids decoding, or rasterizing, the other ones. It is empty for a nil range.
+func (p *pageRange) selector() string {
+	if p == nil {
+		return ""
+	}
+	if p.first == p.last {
+		return fmt.Sprintf("[%d]", p.first-1)
+	}
+	return fmt.Sprintf("[%d-%d]", p.first-1, p.last-1)
+}
+
+// firstPage returns the number of the first page read.
+func (p *pageRange) firstPage() int {
+	if p == nil {
+		return 1
+	}
+	return p.first
+}
+
+// PageFilePath returns the output path of a single page, adding the page number to resizedImageFilePath.
+func PageFilePath(resizedImageFilePath string, page int) string {
+	extension := filepath.Ext(resizedImageFilePath)
+	return fmt.Sprintf("%s_page%d%s", strings.TrimSuffix(resizedImageFilePath, extension), page, extension)
+}
+
+// resizeDocument resizes each page of a multi-page document, such as a TIFF or a PDF. The pages
+// are written either to a single multi-page output, which requires a format holding several
+// images, or, in contact strip mode, appended side by side into a single image.
+func (i *imageResizer) resizeDocument(imageFilePath string, pages int) (string, error) {
+	resizedImageFilePath := i.resizedImageFilePath(imageFilePath)
+	format := i.outputFormatOf(resizedImageFilePath)
+	if !i.contactStrip && !multiImageFormats[format] {
+		// ImageMagick would write each page to a numbered file instead of the returned path.
+		return "", fmt.Errorf("%s cannot hold the %d pages of %s; use ResizePages, WithContactStrip or a multi-page output format such as TIFF or PDF", format, pages, imageFilePath)
+	}
+	for page := 0; page < pages; page++ {
+		i.mw.SetIteratorIndex(page)
+		if err := i.resizeFrame(format); err != nil {
+			return "", errors.Wrapf(err, "page %d", i.pages.firstPage()+page)
+		}
+	}
+	if i.contactStrip {
+		if err := i.mw.AppendAll(false); err != nil {
+			return "", errors.Wrap(err, "appending pages")
+		}
+	}
+	if err := i.mw.WriteImages(resizedImageFilePath, true); err != nil {
+		return "", errors.Wrapf(err, "writing image %s", resizedImageFilePath)
+	}
+	return resizedImageFilePath, nil
+}
+
+// ResizePages resizes each page selected by WithPages, or every page if none was, of the image
+// located at imageFilePath. Each page is written to the resized image file path, with its
+// number, counted from 1, appended to its name, e.g. someDocument_resized_page2.png.
+func (i *imageResizer) ResizePages(imageFilePath string) (_ []string, err error) {
+	defer recoverPanic(&err)
+	if err := i.read(imageFilePath); err != nil {
+		return nil, err
+	}
+	resizedImageFilePath := i.resizedImageFilePath(imageFilePath)
+	pages := int(i.mw.GetNumberImages())
+	resizedImageFilePaths := make([]string, 0, pages)
+	for page := 0; page < pages; page++ {
+		number := i.pages.firstPage() + page
+		i.mw.SetIteratorIndex(page)
+		if err := i.resizeFrame(i.outputFormatOf(resizedImageFilePath)); err != nil {
+			return nil, errors.Wrapf(err, "page %d", number)
+		}
+		pageImageFilePath := PageFilePath(resizedImageFilePath, number)
+		if err := i.mw.WriteImage(pageImageFilePath); err != nil {
+			return nil, errors.Wrapf(err, "writing image %s", pageImageFilePath)
+		}
+		resizedImageFilePaths = append(resizedImageFilePaths, pageImageFilePath)
+	}
+	return resizedImageFilePaths, nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageRange_selector(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pages          *pageRange
+		expectedOutput string
+	}{
+		{
+			name: "all pages",
+		},
+		{
+			name:           "single page",
+			pages:          &pageRange{first: 3, last: 3},
+			expectedOutput: "[2]",
+		},
+		{
+			name:           "range",
+			pages:          &pageRange{first: 1, last: 4},
+			expectedOutput: "[0-3]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, tc.pages.selector())
+		})
+	}
+}
+
+func TestPageRange_validate(t *testing.T) {
+	require.NoError(t, (*pageRange)(nil).validate())
+	require.NoError(t, (&pageRange{first: 2, last: 2}).validate())
+	require.EqualError(t, (&pageRange{first: 0, last: 2}).validate(), "invalid page range 0-2")
+	require.EqualError(t, (&pageRange{first: 3, last: 2}).validate(), "invalid page range 3-2")
+}
+
+func TestPageFilePath(t *testing.T) {
+	require.Equal(t, "path/to/doc_resized_page2.png", PageFilePath("path/to/doc_resized.png", 2))
+	require.Equal(t, "path/to/doc_resized_page2", PageFilePath("path/to/doc_resized", 2))
+}
+
+func Test_resizeDocument(t *testing.T) {
+	testCases := []struct {
+		name           string
+		outputFormat   string
+		contactStrip   bool
+		mockClosure    func(m *mockMagickWand)
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			name:           "multi-page output",
+			expectedOutput: "/path/to/dir/doc_resized.tiff",
+		},
+		{
+			name:           "pdf output",
+			outputFormat:   "pdf",
+			expectedOutput: "/path/to/dir/doc_resized.pdf",
+		},
+		{
+			name:           "contact strip",
+			contactStrip:   true,
+			expectedOutput: "/path/to/dir/doc_resized.tiff",
+		},
+		{
+			name:           "contact strip in a single-image format",
+			outputFormat:   "png",
+			contactStrip:   true,
+			expectedOutput: "/path/to/dir/doc_resized.png",
+		},
+		{
+			name:          "single-image output format",
+			outputFormat:  "png",
+			expectedError: errors.New("PNG cannot hold the 2 pages of doc.tiff; use ResizePages, WithContactStrip or a multi-page output format such as TIFF or PDF"),
+		},
+		{
+			name: "error when resizing a page",
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("page 3: resizing image: resize image error"),
+		},
+		{
+			name:         "error when appending pages",
+			contactStrip: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errAppendAll = errors.New("append all error")
+			},
+			expectedError: errors.New("appending pages: append all error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{numberImages: 2, format: "TIFF"}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:           m,
+				outputDir:    "/path/to/dir",
+				outputFormat: tc.outputFormat,
+				pages:        &pageRange{first: 3, last: 4},
+				contactStrip: tc.contactStrip,
+			}
+			output, err := ir.resizeDocument("doc.tiff", 2)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+				require.Equal(t, tc.expectedOutput, m.writtenImages)
+			}
+		})
+	}
+}
+
+func TestResizePages(t *testing.T) {
+	testCases := []struct {
+		name              string
+		pages             *pageRange
+		density           float64
+		mockClosure       func(m *mockMagickWand)
+		expectedReadImage string
+		expectedOutput    []string
+		expectedError     error
+	}{
+		{
+			name:              "all pages",
+			expectedReadImage: "doc.pdf",
+			expectedOutput: []string{
+				"/path/to/dir/doc_resized_page1.png",
+				"/path/to/dir/doc_resized_page2.png",
+			},
+		},
+		{
+			name:              "page range at a given density",
+			pages:             &pageRange{first: 4, last: 5},
+			density:           150,
+			expectedReadImage: "doc.pdf[3-4]",
+			expectedOutput: []string{
+				"/path/to/dir/doc_resized_page4.png",
+				"/path/to/dir/doc_resized_page5.png",
+			},
+		},
+		{
+			name:          "invalid page range",
+			pages:         &pageRange{first: 5, last: 4},
+			expectedError: errors.New("invalid page range 5-4"),
+		},
+		{
+			name:    "error when setting density",
+			density: 150,
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetResolution = errors.New("set resolution error")
+			},
+			expectedError: errors.New("setting density to 150: set resolution error"),
+		},
+		{
+			name: "error when reading image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image doc.pdf: read image error"),
+		},
+		{
+			name: "error when writing a page",
+			mockClosure: func(m *mockMagickWand) {
+				m.errWriteImage = errors.New("write image error")
+			},
+			expectedError: errors.New("writing image /path/to/dir/doc_resized_page1.png: write image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{numberImages: 2, format: "PDF"}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:           m,
+				outputDir:    "/path/to/dir",
+				outputFormat: "png",
+				pages:        tc.pages,
+				density:      tc.density,
+			}
+			output, err := ir.ResizePages("doc.pdf")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedReadImage, m.readImage)
+				require.Equal(t, tc.expectedOutput, output)
+				require.Equal(t, tc.expectedOutput, m.writtenImage)
+			}
+		})
+	}
+}
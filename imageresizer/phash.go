@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// HashAlgorithm is the algorithm perceptual hashes are computed with.
+type HashAlgorithm int
+
+const (
+	HASH_DIFFERENCE HashAlgorithm = iota // dHash: tells whether each pixel of a 9x8 copy is brighter than its left neighbour.
+	HASH_DCT                             // pHash: tells whether each low frequency of a 32x32 copy is above their median.
+)
+
+const (
+	// dHashWidth is the width of the copy a dHash is computed from; each row yields 8 bits.
+	dHashWidth = 9
+	// dHashHeight is the height of the copy a dHash is computed from.
+	dHashHeight = 8
+	// pHashSide is the side of the copy a pHash is computed from.
+	pHashSide = 32
+	// pHashFrequencies is the number of lowest frequencies, in each direction, a pHash keeps.
+	pHashFrequencies = 8
+	// pHashTolerance is how much a frequency must exceed the median to set its bit. Actual
+	// differences are orders of magnitude larger; it keeps the rounding noise of flat images,
+	// whose frequencies are all zero but the lowest, from making their hashes arbitrary.
+	pHashTolerance = 1e-6
+	// hashFilter is the filter the copies hashes are computed from are scaled down with. It is
+	// fixed, so that hashes are comparable whatever the settings of the resizer.
+	hashFilter = FILTER_TRIANGLE
+)
+
+// PerceptualHash reads a small copy of the first frame of the image located at imageFilePath
+// and hashes it with the algorithm set by WithHashAlgorithm, the difference hash by default.
+func (i *imageResizer) PerceptualHash(imageFilePath string) (_ uint64, err error) {
+	defer recoverPanic(&err)
+	width, height := dHashWidth, dHashHeight
+	if i.hashAlgorithm == HASH_DCT {
+		width, height = pHashSide, pHashSide
+	}
+	mw, err := i.readSmall(imageFilePath, width)
+	if err != nil {
+		return 0, err
+	}
+	defer mw.Destroy()
+	gray, err := grayscale(mw, width, height)
+	if err != nil {
+		return 0, err
+	}
+	if i.hashAlgorithm == HASH_DCT {
+		return pHash(gray), nil
+	}
+	return dHash(gray), nil
+}
+
+// grayscale scales the current image of the wand to width x height, ignoring its aspect
+// ratio, and returns the luma of its pixels, row by row. Translucent pixels are blended over
+// white, so that transparency hashes the same whatever the color it hides.
+func grayscale(mw magickWand, width, height int) ([]float64, error) {
+	if err := mw.ResizeImage(uint(width), uint(height), hashFilter.wandFilter()); err != nil {
+		return nil, errors.Wrapf(err, "scaling image down to %dx%d", width, height)
+	}
+	rgba, err := mw.ExportRGBA()
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting pixels")
+	}
+	gray := make([]float64, width*height)
+	for n := range gray {
+		pixel := rgba[n*4 : n*4+4]
+		alpha := float64(pixel[3]) / 255
+		luma := 0.299*float64(pixel[0]) + 0.587*float64(pixel[1]) + 0.114*float64(pixel[2])
+		gray[n] = luma*alpha + 255*(1-alpha)
+	}
+	return gray, nil
+}
+
+// dHash returns the difference hash of a 9x8 grayscale image: one bit per pixel of the last 8
+// columns, set when the pixel is brighter than its left neighbour, from the top-left one down.
+func dHash(gray []float64) uint64 {
+	var hash uint64
+	for y := 0; y < dHashHeight; y++ {
+		for x := 1; x < dHashWidth; x++ {
+			hash <<= 1
+			if gray[y*dHashWidth+x] > gray[y*dHashWidth+x-1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// pHash returns the DCT hash of a 32x32 grayscale image: one bit per each of its 8x8 lowest
+// frequencies, set when the frequency is above their median, from the lowest one onwards.
+// The median leaves the average brightness out, as it has no bearing on the structure.
+func pHash(gray []float64) uint64 {
+	// The transform is separable, so rows are transformed first, then the columns of the result.
+	var rows [pHashSide][pHashFrequencies]float64
+	for y := 0; y < pHashSide; y++ {
+		for u := 0; u < pHashFrequencies; u++ {
+			for x := 0; x < pHashSide; x++ {
+				rows[y][u] += gray[y*pHashSide+x] * dctBasis(x, u)
+			}
+		}
+	}
+	var frequencies [pHashFrequencies * pHashFrequencies]float64
+	for v := 0; v < pHashFrequencies; v++ {
+		for u := 0; u < pHashFrequencies; u++ {
+			for y := 0; y < pHashSide; y++ {
+				frequencies[v*pHashFrequencies+u] += rows[y][u] * dctBasis(y, v)
+			}
+		}
+	}
+	sorted := append([]float64(nil), frequencies[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	var hash uint64
+	for _, frequency := range frequencies {
+		hash <<= 1
+		if frequency-median > pHashTolerance {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// dctBasis returns the weight of the sample at position n in the frequency k of an unscaled
+// 32-point DCT-II.
+func dctBasis(n, k int) float64 {
+	return math.Cos(math.Pi * float64((2*n+1)*k) / (2 * pHashSide))
+}
+
+// HammingDistance returns the number of bits that differ between two perceptual hashes. The
+// lower it is, the more alike the images are; near-duplicates typically differ by a few bits.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// GroupDuplicates groups the paths whose perceptual hashes differ by at most maxDistance bits,
+// directly or through other paths of the group. Each group is sorted, groups are sorted by
+// their first path, and paths without duplicates are left out.
+func GroupDuplicates(hashes map[string]uint64, maxDistance int) [][]string {
+	paths := make([]string, 0, len(hashes))
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	// Each path points to another one of its group, up to the first path of the group, which
+	// points to itself.
+	parents := make([]int, len(paths))
+	for n := range parents {
+		parents[n] = n
+	}
+	root := func(n int) int {
+		for parents[n] != n {
+			parents[n] = parents[parents[n]]
+			n = parents[n]
+		}
+		return n
+	}
+	for a := range paths {
+		for b := a + 1; b < len(paths); b++ {
+			if HammingDistance(hashes[paths[a]], hashes[paths[b]]) <= maxDistance {
+				ra, rb := root(a), root(b)
+				parents[max(ra, rb)] = min(ra, rb)
+			}
+		}
+	}
+	members := make(map[int][]string)
+	for n, path := range paths {
+		members[root(n)] = append(members[root(n)], path)
+	}
+	var groups [][]string
+	for n := range paths {
+		if group := members[n]; len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
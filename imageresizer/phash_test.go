@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerceptualHash(t *testing.T) {
+	testCases := []struct {
+		name            string
+		hashAlgorithm   HashAlgorithm
+		mockClosure     func(m *mockMagickWand)
+		expectedHash    uint64
+		expectedWidth   uint
+		expectedHeight  uint
+		expectedOptions map[string]string
+		expectedError   error
+	}{
+		{
+			name:            "difference hash",
+			expectedWidth:   9,
+			expectedHeight:  8,
+			expectedOptions: map[string]string{"jpeg:size": "9x9"},
+		},
+		{
+			name:            "dct hash",
+			hashAlgorithm:   HASH_DCT,
+			expectedHash:    1 << 63,
+			expectedWidth:   32,
+			expectedHeight:  32,
+			expectedOptions: map[string]string{"jpeg:size": "32x32"},
+		},
+		{
+			name: "error when setting jpeg size hint",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting jpeg:size to 9x9: set option error"),
+		},
+		{
+			name: "error when reading image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image someImage.jpg: read image error"),
+		},
+		{
+			name:          "error when scaling image down",
+			hashAlgorithm: HASH_DCT,
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("scaling image down to 32x32: resize image error"),
+		},
+		{
+			name: "error when exporting pixels",
+			mockClosure: func(m *mockMagickWand) {
+				m.errExportRGBA = errors.New("export rgba error")
+			},
+			expectedError: errors.New("exporting pixels: export rgba error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{width: 1200, height: 850, rgba: [4]byte{30, 144, 255, 255}}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				hashAlgorithm: tc.hashAlgorithm,
+				newMagickWand: func() magickWand { return m },
+			}
+			hash, err := ir.PerceptualHash("someImage.jpg")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedHash, hash)
+				require.Equal(t, tc.expectedWidth, m.width)
+				require.Equal(t, tc.expectedHeight, m.height)
+				require.Equal(t, tc.expectedOptions, m.options)
+			}
+		})
+	}
+}
+
+func Test_grayscale(t *testing.T) {
+	m := &mockMagickWand{width: 1200, height: 850, rgba: [4]byte{255, 0, 0, 255}}
+	gray, err := grayscale(m, 2, 1)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{76.245, 76.245}, gray, 1e-9)
+
+	m.rgba = [4]byte{0, 0, 0, 0}
+	gray, err = grayscale(m, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []float64{255, 255}, gray, "transparent pixels are white")
+}
+
+func Test_dHash(t *testing.T) {
+	testCases := []struct {
+		name         string
+		pixel        func(x, y int) float64
+		expectedHash uint64
+	}{
+		{
+			name:         "brighter to the right",
+			pixel:        func(x, y int) float64 { return float64(x * 10) },
+			expectedHash: 0xffffffffffffffff,
+		},
+		{
+			name:         "darker to the right",
+			pixel:        func(x, y int) float64 { return float64(255 - x*10) },
+			expectedHash: 0,
+		},
+		{
+			name:         "brighter to the right on the top half",
+			pixel:        func(x, y int) float64 { return float64(x * (4 - y)) },
+			expectedHash: 0xffffffff00000000,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gray := make([]float64, dHashWidth*dHashHeight)
+			for n := range gray {
+				gray[n] = tc.pixel(n%dHashWidth, n/dHashWidth)
+			}
+			require.Equal(t, tc.expectedHash, dHash(gray))
+		})
+	}
+}
+
+func Test_pHash(t *testing.T) {
+	gray := make([]float64, pHashSide*pHashSide)
+	for n := range gray {
+		x, y := n%pHashSide, n/pHashSide
+		gray[n] = float64((x*7 + y*y*3 + (x*y)%13) % 256)
+	}
+	require.Equal(t, uint64(0x958b9eb10e2f352c), pHash(gray))
+
+	// Changing brightness and contrast scales the frequencies and offsets the average only.
+	adjusted := make([]float64, len(gray))
+	for n, value := range gray {
+		adjusted[n] = value*0.5 + 40
+	}
+	require.Equal(t, pHash(gray), pHash(adjusted))
+}
+
+func TestHammingDistance(t *testing.T) {
+	require.Equal(t, 0, HammingDistance(0x958b9eb10e2f352c, 0x958b9eb10e2f352c))
+	require.Equal(t, 2, HammingDistance(0b1011, 0b1000))
+	require.Equal(t, 64, HammingDistance(0, 0xffffffffffffffff))
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	hashes := map[string]uint64{
+		"e.jpg": 0xff00,
+		"a.jpg": 0x0000,
+		"d.jpg": 0x0001,
+		"b.jpg": 0xf0f0,
+		"c.jpg": 0x0007,
+		"f.jpg": 0xff01,
+	}
+	require.Equal(t, [][]string{
+		{"a.jpg", "c.jpg", "d.jpg"},
+		{"e.jpg", "f.jpg"},
+	}, GroupDuplicates(hashes, 2))
+	require.Equal(t, [][]string{{"a.jpg", "d.jpg"}, {"e.jpg", "f.jpg"}}, GroupDuplicates(hashes, 1))
+	require.Empty(t, GroupDuplicates(hashes, 0))
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// blurHashMaxSide is the largest side, in pixels, of the copy of the image a BlurHash is
+	// computed from. Its few components do not need more detail.
+	blurHashMaxSide = 32
+	// defaultBlurHashXComponents is the number of horizontal BlurHash components used when none is set.
+	defaultBlurHashXComponents = 4
+	// defaultBlurHashYComponents is the number of vertical BlurHash components used when none is set.
+	defaultBlurHashYComponents = 3
+)
+
+// PlaceholderOptions selects the placeholders computed for an image. When none of BlurHash,
+// ThumbHash and LQIP is set, both a BlurHash and a ThumbHash are computed.
+type PlaceholderOptions struct {
+	BlurHash    bool // Whether to compute a BlurHash.
+	XComponents int  // Number of horizontal BlurHash components, from 1 to 9; defaults to 4.
+	YComponents int  // Number of vertical BlurHash components, from 1 to 9; defaults to 3.
+	ThumbHash   bool // Whether to compute a ThumbHash.
+
+	LQIP        bool    // Whether to compute a low-quality image placeholder, as a data URI.
+	LQIPSize    int     // Longest side of the LQIP, in pixels; defaults to 20.
+	LQIPFormat  string  // Format of the LQIP, e.g. "webp" or "jpeg"; defaults to WebP.
+	LQIPQuality int     // Compression quality of the LQIP; defaults to 30.
+	LQIPBlur    float64 // Standard deviation of the blur applied to the LQIP, in pixels; defaults to 1.
+}
+
+// withDefaults returns a copy of the options with the defaults applied.
+func (o PlaceholderOptions) withDefaults() PlaceholderOptions {
+	if !o.BlurHash && !o.ThumbHash && !o.LQIP {
+		o.BlurHash, o.ThumbHash = true, true
+	}
+	if o.XComponents == 0 {
+		o.XComponents = defaultBlurHashXComponents
+	}
+	if o.YComponents == 0 {
+		o.YComponents = defaultBlurHashYComponents
+	}
+	if o.LQIPSize == 0 {
+		o.LQIPSize = defaultLQIPSize
+	}
+	if o.LQIPFormat == "" {
+		o.LQIPFormat = defaultLQIPFormat
+	}
+	if o.LQIPQuality == 0 {
+		o.LQIPQuality = defaultLQIPQuality
+	}
+	if o.LQIPBlur == 0 {
+		o.LQIPBlur = defaultLQIPBlur
+	}
+	return o
+}
+
+// Placeholder holds compact representations of an image, which can be rendered as a blurred
+// preview while the image loads.
+type Placeholder struct {
+	BlurHash  string // BlurHash of the image; empty when not computed.
+	ThumbHash string // ThumbHash of the image, base64-encoded; empty when not computed.
+	LQIP      string // Low-quality image placeholder, as a data URI; empty when not computed.
+}
+
+// Placeholder reads a small copy of the first frame of the image located at imageFilePath
+// and computes the placeholders selected by WithPlaceholder from it, or both a BlurHash and a
+// ThumbHash with the default options if none was.
+func (i *imageResizer) Placeholder(imageFilePath string) (_ Placeholder, err error) {
+	defer recoverPanic(&err)
+	mw, err := i.readSmall(imageFilePath, thumbHashMaxSide)
+	if err != nil {
+		return Placeholder{}, err
+	}
+	defer mw.Destroy()
+	return i.placeholder(mw)
+}
+
+// resizedPlaceholder returns the placeholders of the resized image, if any was requested.
+func (i *imageResizer) resizedPlaceholder() (Placeholder, error) {
+	if i.placeholderOptions == nil {
+		return Placeholder{}, nil
+	}
+	mw := i.mw.CloneImage()
+	defer mw.Destroy()
+	placeholder, err := i.placeholder(mw)
+	if err != nil {
+		return Placeholder{}, errors.Wrap(err, "computing placeholder")
+	}
+	return placeholder, nil
+}
+
+// placeholder computes the placeholders of the current image of the wand, which is scaled down
+// in the process. The LQIP is created from a copy, as it is blurred.
+func (i *imageResizer) placeholder(mw magickWand) (Placeholder, error) {
+	var options PlaceholderOptions
+	if i.placeholderOptions != nil {
+		options = *i.placeholderOptions
+	}
+	options = options.withDefaults()
+	var placeholder Placeholder
+	if options.LQIP {
+		uri, err := lqip(mw, options, i.filterType)
+		if err != nil {
+			return Placeholder{}, errors.Wrap(err, "creating lqip")
+		}
+		placeholder.LQIP = uri
+	}
+	// The ThumbHash needs the larger copy, so it is computed first.
+	if options.ThumbHash {
+		rgba, width, height, err := tinyRGBA(mw, thumbHashMaxSide, i.filterType)
+		if err != nil {
+			return Placeholder{}, err
+		}
+		hash, err := thumbHash(width, height, rgba)
+		if err != nil {
+			return Placeholder{}, errors.Wrap(err, "encoding thumbhash")
+		}
+		placeholder.ThumbHash = base64.StdEncoding.EncodeToString(hash)
+	}
+	if options.BlurHash {
+		rgba, width, height, err := tinyRGBA(mw, blurHashMaxSide, i.filterType)
+		if err != nil {
+			return Placeholder{}, err
+		}
+		hash, err := blurHash(options.XComponents, options.YComponents, width, height, rgba)
+		if err != nil {
+			return Placeholder{}, errors.Wrap(err, "encoding blurhash")
+		}
+		placeholder.BlurHash = hash
+	}
+	return placeholder, nil
+}
+
+// tinyRGBA scales the current image of the wand down to fit in maxSide x maxSide, unless it
+// already does, and returns its RGBA pixels along with its dimensions.
+func tinyRGBA(mw magickWand, maxSide int, filter FilterType) ([]byte, int, int, error) {
+	width, height := int(mw.GetImageWidth()), int(mw.GetImageHeight())
+	if width > maxSide || height > maxSide {
+		width, height = fitDimensions(width, height, maxSide, maxSide)
+		if err := mw.ResizeImage(uint(width), uint(height), filter.wandFilter()); err != nil {
+			return nil, 0, 0, errors.Wrapf(err, "scaling image down to %dx%d", width, height)
+		}
+	}
+	rgba, err := mw.ExportRGBA()
+	if err != nil {
+		return nil, 0, 0, errors.Wrap(err, "exporting pixels")
+	}
+	return rgba, width, height, nil
+}
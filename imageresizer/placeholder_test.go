@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceholder(t *testing.T) {
+	red := [4]byte{255, 0, 0, 255}
+	expectedBlurHash := func(xComponents, yComponents int) string {
+		hash, err := blurHash(xComponents, yComponents, 32, 23, solidRGBA(32, 23, red))
+		require.NoError(t, err)
+		return hash
+	}
+	thumb, err := thumbHash(100, 71, solidRGBA(100, 71, red))
+	require.NoError(t, err)
+	expectedThumbHash := base64.StdEncoding.EncodeToString(thumb)
+	testCases := []struct {
+		name                string
+		placeholderOptions  *PlaceholderOptions
+		mockClosure         func(m *mockMagickWand)
+		expectedPlaceholder Placeholder
+		expectedError       error
+	}{
+		{
+			name:                "both by default",
+			expectedPlaceholder: Placeholder{BlurHash: expectedBlurHash(4, 3), ThumbHash: expectedThumbHash},
+		},
+		{
+			name:                "blurhash with components",
+			placeholderOptions:  &PlaceholderOptions{BlurHash: true, XComponents: 5, YComponents: 4},
+			expectedPlaceholder: Placeholder{BlurHash: expectedBlurHash(5, 4)},
+		},
+		{
+			name:                "thumbhash",
+			placeholderOptions:  &PlaceholderOptions{ThumbHash: true},
+			expectedPlaceholder: Placeholder{ThumbHash: expectedThumbHash},
+		},
+		{
+			name:                "lqip",
+			placeholderOptions:  &PlaceholderOptions{LQIP: true},
+			expectedPlaceholder: Placeholder{LQIP: "data:image/webp;base64," + base64.StdEncoding.EncodeToString(make([]byte, 30000))},
+		},
+		{
+			name:               "error when creating lqip",
+			placeholderOptions: &PlaceholderOptions{LQIP: true},
+			mockClosure: func(m *mockMagickWand) {
+				m.errGaussianBlurImage = errors.New("gaussian blur image error")
+			},
+			expectedError: errors.New("creating lqip: blurring image: gaussian blur image error"),
+		},
+		{
+			name: "error when setting jpeg size hint",
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetOption = errors.New("set option error")
+			},
+			expectedError: errors.New("setting jpeg:size to 100x100: set option error"),
+		},
+		{
+			name: "error when reading image",
+			mockClosure: func(m *mockMagickWand) {
+				m.errReadImage = errors.New("read image error")
+			},
+			expectedError: errors.New("reading image someImage.jpg: read image error"),
+		},
+		{
+			name: "error when scaling image down",
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("scaling image down to 100x71: resize image error"),
+		},
+		{
+			name: "error when exporting pixels",
+			mockClosure: func(m *mockMagickWand) {
+				m.errExportRGBA = errors.New("export rgba error")
+			},
+			expectedError: errors.New("exporting pixels: export rgba error"),
+		},
+		{
+			name:               "error when encoding blurhash",
+			placeholderOptions: &PlaceholderOptions{BlurHash: true, XComponents: 12},
+			expectedError:      errors.New("encoding blurhash: blurhash components must be between 1 and 9, got 12x3"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{width: 1200, height: 850, rgba: red}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				placeholderOptions: tc.placeholderOptions,
+				newMagickWand:      func() magickWand { return m },
+			}
+			placeholder, err := ir.Placeholder("someImage.jpg")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedPlaceholder, placeholder)
+				require.Equal(t, map[string]string{"jpeg:size": "100x100"}, m.options)
+			}
+		})
+	}
+}
+
+func Test_resizedPlaceholder(t *testing.T) {
+	m := &mockMagickWand{width: 20, height: 10, rgba: [4]byte{0, 0, 255, 255}}
+	ir := &imageResizer{mw: m}
+	placeholder, err := ir.resizedPlaceholder()
+	require.NoError(t, err)
+	require.Equal(t, Placeholder{}, placeholder)
+
+	ir.placeholderOptions = &PlaceholderOptions{BlurHash: true}
+	placeholder, err = ir.resizedPlaceholder()
+	require.NoError(t, err)
+	expected, err := blurHash(4, 3, 20, 10, solidRGBA(20, 10, m.rgba))
+	require.NoError(t, err)
+	require.Equal(t, Placeholder{BlurHash: expected}, placeholder)
+	require.Equal(t, 0, m.resizes, "images smaller than the placeholder copy are not scaled")
+
+	m.errExportRGBA = errors.New("export rgba error")
+	_, err = ir.resizedPlaceholder()
+	require.EqualError(t, err, "computing placeholder: exporting pixels: export rgba error")
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+// UntrustedInputPolicy is an ImageMagick security policy, in the format of its policy.xml, for
+// images coming from untrusted sources such as uploads. It bounds the dimensions, pixels,
+// memory and frames ImageMagick accepts, and refuses the inputs that can make it read other
+// files or run programs: delegates, indirect filenames such as "@file", the MVG and MSL
+// drawing languages, SVG, text, URLs and PostScript or PDF documents. Vector sources and
+// multi-page documents other than TIFF are therefore refused under it.
+const UntrustedInputPolicy = `<policymap>
+  <policy domain="resource" name="width" value="16KP"/>
+  <policy domain="resource" name="height" value="16KP"/>
+  <policy domain="resource" name="area" value="128MP"/>
+  <policy domain="resource" name="memory" value="256MiB"/>
+  <policy domain="resource" name="map" value="512MiB"/>
+  <policy domain="resource" name="disk" value="1GiB"/>
+  <policy domain="resource" name="list-length" value="1000"/>
+  <policy domain="delegate" rights="none" pattern="*"/>
+  <policy domain="path" rights="none" pattern="@*"/>
+  <policy domain="coder" rights="none" pattern="{MVG,MSL,SVG,SVGZ,MSVG,RSVG,TEXT,TXT,LABEL,CAPTION,PANGO,URL,HTTP,HTTPS,FTP,FILE,EPHEMERAL,SHOW,WIN,X,PLT,PS,PS2,PS3,EPS,EPI,EPSI,EPSF,PDF,EPDF,AI,XPS}"/>
+</policymap>`
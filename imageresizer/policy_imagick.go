@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build cgo && !nomagick
+
+package imageresizer
+
+/*
+#cgo !no_pkgconfig pkg-config: MagickCore
+#include <stdlib.h>
+#include <MagickCore/MagickCore.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"gopkg.in/gographics/imagick.v3/imagick"
+)
+
+// securityPolicies holds the policies set with SetSecurityPolicy, in order, so that they can
+// be applied again after Terminate, which unloads them along with ImageMagick.
+var securityPolicies struct {
+	sync.Mutex
+	policies []string // Policies set, in the order they were set.
+	unloaded bool     // Whether ImageMagick was terminated since the policies were applied.
+}
+
+// SetSecurityPolicy adds the rules of policy, in the format of ImageMagick's policy.xml (see
+// UntrustedInputPolicy), to the security policy of the process, where they take precedence over
+// the rules already loaded. It applies to every resizer, including those created before, but
+// not to those processing images in memory. Terminate unloads the policies along with
+// ImageMagick, which then reloads its system policy.xml when it is initialized again; the
+// policies set are applied again when the next resizer is created, before it reads any image.
+func SetSecurityPolicy(policy string) error {
+	securityPolicies.Lock()
+	defer securityPolicies.Unlock()
+	imagick.Initialize() // The policy cache must be loaded before rules are added to it.
+	if err := reloadSecurityPolicies(); err != nil {
+		return err
+	}
+	if err := applySecurityPolicy(policy); err != nil {
+		return err
+	}
+	securityPolicies.policies = append(securityPolicies.policies, policy)
+	return nil
+}
+
+// restoreSecurityPolicies applies the policies set again if ImageMagick was terminated since
+// they were applied. ImageMagick must be initialized.
+func restoreSecurityPolicies() error {
+	securityPolicies.Lock()
+	defer securityPolicies.Unlock()
+	return reloadSecurityPolicies()
+}
+
+// reloadSecurityPolicies is restoreSecurityPolicies, for callers holding the lock.
+func reloadSecurityPolicies() error {
+	if !securityPolicies.unloaded {
+		return nil
+	}
+	for _, policy := range securityPolicies.policies {
+		if err := applySecurityPolicy(policy); err != nil {
+			return err
+		}
+	}
+	securityPolicies.unloaded = false
+	return nil
+}
+
+// applySecurityPolicy adds the rules of policy to the security policy of ImageMagick.
+func applySecurityPolicy(policy string) error {
+	cPolicy := C.CString(policy)
+	defer C.free(unsafe.Pointer(cPolicy))
+	exception := C.AcquireExceptionInfo()
+	defer C.DestroyExceptionInfo(exception)
+	if C.SetMagickSecurityPolicy(cPolicy, exception) == C.MagickFalse {
+		if exception.reason != nil {
+			return fmt.Errorf("setting security policy: %s", C.GoString(exception.reason))
+		}
+		return fmt.Errorf("setting security policy: invalid policy")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import "github.com/pkg/errors"
+
+// ErrPanic is wrapped by the errors returned instead of panicking when processing an image
+// panics, e.g. in ImageMagick's bindings on a malformed upload.
+var ErrPanic = errors.New("panic while processing image")
+
+// recoverPanic recovers from a panic of the calling function, making it return an error
+// wrapping ErrPanic instead. It must be deferred by functions with a named error result.
+// Crashes within C code, such as segmentation faults, are not panics and cannot be recovered.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = errors.Wrapf(ErrPanic, "%v", r)
+	}
+}
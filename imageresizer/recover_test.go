@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// panickingWand is a magickWand whose every method panics, as a nil pointer dereference.
+type panickingWand struct {
+	magickWand
+}
+
+func TestRecoverPanic(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "someImage.jpg")
+	require.NoError(t, os.WriteFile(input, []byte("someImage"), 0o644))
+	testCases := []struct {
+		name string
+		call func(ir *imageResizer) error
+	}{
+		{
+			name: "Resize",
+			call: func(ir *imageResizer) error {
+				_, err := ir.Resize(input)
+				return err
+			},
+		},
+		{
+			name: "ResizeWithResult",
+			call: func(ir *imageResizer) error {
+				_, err := ir.ResizeWithResult(input)
+				return err
+			},
+		},
+		{
+			name: "ResizePages",
+			call: func(ir *imageResizer) error {
+				_, err := ir.ResizePages(input)
+				return err
+			},
+		},
+		{
+			name: "Inspect",
+			call: func(ir *imageResizer) error {
+				_, err := ir.Inspect(input)
+				return err
+			},
+		},
+		{
+			name: "InspectReader",
+			call: func(ir *imageResizer) error {
+				_, err := ir.InspectReader(strings.NewReader("someImage"))
+				return err
+			},
+		},
+		{
+			name: "Placeholder",
+			call: func(ir *imageResizer) error {
+				_, err := ir.Placeholder(input)
+				return err
+			},
+		},
+		{
+			name: "Colors",
+			call: func(ir *imageResizer) error {
+				_, err := ir.Colors(input)
+				return err
+			},
+		},
+		{
+			name: "PerceptualHash",
+			call: func(ir *imageResizer) error {
+				_, err := ir.PerceptualHash(input)
+				return err
+			},
+		},
+		{
+			name: "Compare",
+			call: func(ir *imageResizer) error {
+				_, err := ir.Compare(input, input)
+				return err
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := &imageResizer{
+				mw:            panickingWand{},
+				newMagickWand: func() magickWand { return panickingWand{} },
+			}
+			err := tc.call(ir)
+			require.True(t, errors.Is(err, ErrPanic))
+			require.Equal(t, "runtime error: invalid memory address or nil pointer dereference: panic while processing image", err.Error())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import "math"
+
+// unsharpMask holds the parameters of the unsharp mask applied after resizing.
+type unsharpMask struct {
+	radius    float64 // Radius of the Gaussian, in pixels, not counting the center pixel; 0 lets ImageMagick pick one.
+	sigma     float64 // Standard deviation of the Gaussian, in pixels.
+	amount    float64 // Fraction of the difference between the original and the blur added back into the original.
+	threshold float64 // Minimum difference, as a fraction of QuantumRange, needed to apply the amount.
+}
+
+// autoUnsharpMask returns unsharp mask parameters suited to the given reduction factor,
+// which is the ratio between the original and the resized dimensions. The stronger the
+// reduction, the softer the downscaled image tends to be, so sigma and amount grow with
+// it. It returns nil when the image is not being reduced, as there is nothing to recover.
+func autoUnsharpMask(reductionFactor float64) *unsharpMask {
+	if reductionFactor <= 1 {
+		return nil
+	}
+	steps := math.Log2(reductionFactor)
+	return &unsharpMask{
+		sigma:     math.Min(0.5+0.2*steps, 1.2),
+		amount:    math.Min(0.6+0.2*steps, 1.4),
+		threshold: 0.02,
+	}
+}
+
+// reductionFactor returns the largest ratio between the original and the new dimensions.
+func reductionFactor(originalWidth, originalHeight, newWidth, newHeight int) float64 {
+	if newWidth <= 0 || newHeight <= 0 {
+		return 1
+	}
+	return math.Max(
+		float64(originalWidth)/float64(newWidth),
+		float64(originalHeight)/float64(newHeight),
+	)
+}
+
+// sharpen applies the configured unsharp mask to the resized image, which must not have been
+// padded yet. When automatic sharpening is enabled, the mask parameters are derived from how
+// much the image was reduced.
+func (i *imageResizer) sharpen(originalWidth, originalHeight int) error {
+	mask := i.unsharpMask
+	if i.autoSharpen {
+		width, height := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+		mask = autoUnsharpMask(reductionFactor(originalWidth, originalHeight, width, height))
+	}
+	if mask == nil {
+		return nil
+	}
+	return i.mw.UnsharpMaskImage(mask.radius, mask.sigma, mask.amount, mask.threshold)
+}
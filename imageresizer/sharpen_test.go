@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_autoUnsharpMask(t *testing.T) {
+	testCases := []struct {
+		name            string
+		reductionFactor float64
+		expectedOutput  *unsharpMask
+	}{
+		{
+			name:            "upscaling",
+			reductionFactor: 0.5,
+		},
+		{
+			name:            "same size",
+			reductionFactor: 1,
+		},
+		{
+			name:            "halving",
+			reductionFactor: 2,
+			expectedOutput:  &unsharpMask{sigma: 0.7, amount: 0.8, threshold: 0.02},
+		},
+		{
+			name:            "strong reduction is capped",
+			reductionFactor: 1024,
+			expectedOutput:  &unsharpMask{sigma: 1.2, amount: 1.4, threshold: 0.02},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := autoUnsharpMask(tc.reductionFactor)
+			if tc.expectedOutput == nil {
+				require.Nil(t, output)
+				return
+			}
+			require.NotNil(t, output)
+			require.InDelta(t, tc.expectedOutput.sigma, output.sigma, 1e-9)
+			require.InDelta(t, tc.expectedOutput.amount, output.amount, 1e-9)
+			require.Equal(t, tc.expectedOutput.threshold, output.threshold)
+		})
+	}
+}
+
+func Test_reductionFactor(t *testing.T) {
+	testCases := []struct {
+		name                                               string
+		originalWidth, originalHeight, newWidth, newHeight int
+		expectedOutput                                     float64
+	}{
+		{
+			name:           "width is the most reduced",
+			originalWidth:  1200,
+			originalHeight: 850,
+			newWidth:       300,
+			newHeight:      425,
+			expectedOutput: 4,
+		},
+		{
+			name:           "height is the most reduced",
+			originalWidth:  1200,
+			originalHeight: 850,
+			newWidth:       600,
+			newHeight:      85,
+			expectedOutput: 10,
+		},
+		{
+			name:           "invalid dimensions",
+			originalWidth:  1200,
+			originalHeight: 850,
+			expectedOutput: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := reductionFactor(tc.originalWidth, tc.originalHeight, tc.newWidth, tc.newHeight)
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import "fmt"
+
+// jpegSizeHint returns the size hint given to the JPEG decoder of the image described by info,
+// or an empty string for none. With it, libjpeg scales the image down in the DCT domain while
+// decoding, by the largest of 1/2, 1/4 and 1/8 that keeps it at least as large as the hint,
+// which is much cheaper than decoding it at full size. The precise resize happens afterward.
+// No hint is given when the image is trimmed, as the dimensions then apply to a part of it only,
+// nor when no dimensions are set.
+func (i *imageResizer) jpegSizeHint(info ImageInfo) string {
+	if i.exactDecode || i.trimming != nil || info.Format != "JPEG" || i.newWidth == nil || i.newHeight == nil {
+		return ""
+	}
+	width, height := *i.newWidth, *i.newHeight
+	if info.transposed() {
+		// The dimensions refer to the image as displayed, while the decoder works on it as stored.
+		width, height = height, width
+	}
+	if i.rotation != nil {
+		// The dimensions refer to the rotated image. Whatever the angle, neither side of the
+		// source exceeds the longest side of the rotated bounding box.
+		width, height = max(width, height), max(width, height)
+	}
+	if width*2 > info.Width || height*2 > info.Height {
+		return "" // Not even a 1/2 reduction is possible.
+	}
+	return fmt.Sprintf("%dx%d", width, height)
+}
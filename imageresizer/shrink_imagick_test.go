@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build cgo && !nomagick
+
+package imageresizer
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkResizeLargeJPEG measures how much faster a 48-megapixel JPEG is turned into a
+// 400px thumbnail when it is scaled down while being decoded. It needs ImageMagick, since the
+// image exceeds what the in-memory backend decodes.
+func BenchmarkResizeLargeJPEG(b *testing.B) {
+	imageFilePath := writeLargeJPEG(b, 8000, 6000)
+	benchmarks := []struct {
+		name    string
+		options []Option
+	}{
+		{name: "shrink on load"},
+		{name: "exact decode", options: []Option{WithExactDecode()}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			options := append([]Option{WithDimensions(400, 300), WithOutputDir(b.TempDir())}, bm.options...)
+			ir := New(options...)
+			defer ir.Destroy()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if _, err := ir.Resize(imageFilePath); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// writeLargeJPEG writes a width x height JPEG with a gradient to a temporary directory,
+// returning its path.
+func writeLargeJPEG(b *testing.B, width, height int) string {
+	b.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+		}
+	}
+	imageFilePath := filepath.Join(b.TempDir(), "large.jpg")
+	file, err := os.Create(imageFilePath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	if err := jpeg.Encode(file, img, &jpeg.Options{Quality: 90}); err != nil {
+		b.Fatal(err)
+	}
+	return imageFilePath
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jpegSizeHint(t *testing.T) {
+	testCases := []struct {
+		name           string
+		info           ImageInfo
+		newWidth       *int
+		newHeight      *int
+		trimming       *Trim
+		rotation       *rotation
+		exactDecode    bool
+		expectedOutput string
+	}{
+		{
+			name:           "large jpeg",
+			info:           ImageInfo{Format: "JPEG", Width: 8000, Height: 6000},
+			newWidth:       IntPtr(400),
+			newHeight:      IntPtr(300),
+			expectedOutput: "400x300",
+		},
+		{
+			name:           "rotated jpeg",
+			info:           ImageInfo{Format: "JPEG", Width: 8000, Height: 6000},
+			newWidth:       IntPtr(300),
+			newHeight:      IntPtr(400),
+			rotation:       &rotation{degrees: 90},
+			expectedOutput: "400x400",
+		},
+		{
+			name:           "jpeg transposed by its orientation",
+			info:           ImageInfo{Format: "JPEG", Width: 8000, Height: 6000, Orientation: 6},
+			newWidth:       IntPtr(300),
+			newHeight:      IntPtr(400),
+			expectedOutput: "400x300",
+		},
+		{
+			name: "no dimensions",
+			info: ImageInfo{Format: "JPEG", Width: 8000, Height: 6000},
+		},
+		{
+			name:      "jpeg too small to be reduced",
+			info:      ImageInfo{Format: "JPEG", Width: 1200, Height: 850},
+			newWidth:  IntPtr(800),
+			newHeight: IntPtr(300),
+		},
+		{
+			name:      "trimmed jpeg",
+			info:      ImageInfo{Format: "JPEG", Width: 8000, Height: 6000},
+			newWidth:  IntPtr(400),
+			newHeight: IntPtr(300),
+			trimming:  &Trim{},
+		},
+		{
+			name:        "exact decode",
+			info:        ImageInfo{Format: "JPEG", Width: 8000, Height: 6000},
+			newWidth:    IntPtr(400),
+			newHeight:   IntPtr(300),
+			exactDecode: true,
+		},
+		{
+			name:      "png",
+			info:      ImageInfo{Format: "PNG", Width: 8000, Height: 6000},
+			newWidth:  IntPtr(400),
+			newHeight: IntPtr(300),
+		},
+		{
+			name: "no dimensions",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := &imageResizer{
+				newWidth:    tc.newWidth,
+				newHeight:   tc.newHeight,
+				trimming:    tc.trimming,
+				rotation:    tc.rotation,
+				exactDecode: tc.exactDecode,
+			}
+			require.Equal(t, tc.expectedOutput, ir.jpegSizeHint(tc.info))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxQuality is the highest compression quality tried when searching for one that fits
+	// the output size, if no compression quality is set.
+	maxQuality = 100
+	// downscaleFactor is the factor by which the dimensions are reduced when no compression
+	// quality fits the output size.
+	downscaleFactor = 0.9
+	// minDownscaledSide is the side, in pixels, below which the dimensions are no longer reduced.
+	minDownscaledSide = 16
+)
+
+// fitOutputSize sets the compression quality of the resized image to the highest one, up to
+// highest, whose encoded output fits in maxOutputBytes, returning it. Outputs are encoded in
+// memory, and the quality is found by binary search, as the output size grows with it. If no
+// quality fits and downscaling is allowed, the dimensions are reduced step by step until one does.
+func (i *imageResizer) fitOutputSize(format string, highest int) (int, error) {
+	if err := i.mw.SetImageFormat(format); err != nil {
+		return 0, errors.Wrapf(err, "setting image format to %s", format)
+	}
+	if highest <= 0 {
+		highest = maxQuality
+	}
+	for {
+		quality, err := i.searchQuality(highest)
+		if err != nil {
+			return 0, err
+		}
+		if quality > 0 {
+			return quality, i.mw.SetImageCompressionQuality(uint(quality))
+		}
+		width, height := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+		if !i.allowDownscale || min(width, height) <= minDownscaledSide {
+			return 0, fmt.Errorf("output does not fit even at the lowest quality")
+		}
+		width, height = scaledDimensions(width, height, downscaleFactor)
+		if err := i.mw.ResizeImage(uint(width), uint(height), i.filterType.wandFilter()); err != nil {
+			return 0, errors.Wrap(err, "reducing dimensions")
+		}
+	}
+}
+
+// searchQuality returns the highest compression quality, up to highest, at which the current
+// image encodes in maxOutputBytes, or zero if none does.
+func (i *imageResizer) searchQuality(highest int) (int, error) {
+	found := 0
+	low, high := 1, highest
+	for low <= high {
+		quality := (low + high) / 2
+		size, err := i.encodedSize(quality)
+		if err != nil {
+			return 0, err
+		}
+		if size <= i.maxOutputBytes {
+			found, low = quality, quality+1
+		} else {
+			high = quality - 1
+		}
+	}
+	return found, nil
+}
+
+// encodedSize returns the size, in bytes, of the current image encoded at the given quality.
+func (i *imageResizer) encodedSize(quality int) (int, error) {
+	blob, err := i.encode(quality)
+	if err != nil {
+		return 0, err
+	}
+	return len(blob), nil
+}
+
+// encode returns the current image encoded in memory at the given quality.
+func (i *imageResizer) encode(quality int) ([]byte, error) {
+	if err := i.mw.SetImageCompressionQuality(uint(quality)); err != nil {
+		return nil, errors.Wrapf(err, "setting image compression quality to %d", quality)
+	}
+	blob, err := i.mw.GetImageBlob()
+	if err != nil {
+		return nil, errors.Wrapf(err, "encoding image at quality %d", quality)
+	}
+	return blob, nil
+}
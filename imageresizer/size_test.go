@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fitOutputSize(t *testing.T) {
+	testCases := []struct {
+		name               string
+		compressionQuality int
+		maxOutputBytes     int
+		allowDownscale     bool
+		mockClosure        func(m *mockMagickWand)
+		expectedQuality    int
+		expectedWidth      uint
+		expectedHeight     uint
+		expectedError      error
+	}{
+		{
+			name:            "highest quality that fits",
+			maxOutputBytes:  5100,
+			expectedQuality: 50,
+			expectedWidth:   1200,
+			expectedHeight:  850,
+		},
+		{
+			name:               "never above the compression quality",
+			compressionQuality: 40,
+			maxOutputBytes:     60000,
+			expectedQuality:    40,
+			expectedWidth:      1200,
+			expectedHeight:     850,
+		},
+		{
+			name:            "reduces dimensions when no quality fits",
+			maxOutputBytes:  90,
+			allowDownscale:  true,
+			expectedQuality: 1,
+			expectedWidth:   1080,
+			expectedHeight:  765,
+		},
+		{
+			name:           "error when no quality fits",
+			maxOutputBytes: 90,
+			expectedError:  errors.New("output does not fit even at the lowest quality"),
+		},
+		{
+			name:           "error when no dimensions fit",
+			maxOutputBytes: 90,
+			allowDownscale: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.blobSize = func(quality, width, height uint) int { return 100 }
+			},
+			expectedError: errors.New("output does not fit even at the lowest quality"),
+		},
+		{
+			name:           "error when setting image format",
+			maxOutputBytes: 90,
+			mockClosure: func(m *mockMagickWand) {
+				m.errSetImageFormat = errors.New("set image format error")
+			},
+			expectedError: errors.New("setting image format to JPEG: set image format error"),
+		},
+		{
+			name:           "error when encoding image",
+			maxOutputBytes: 90,
+			mockClosure: func(m *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			expectedError: errors.New("encoding image at quality 50: get image blob error"),
+		},
+		{
+			name:           "error when reducing dimensions",
+			maxOutputBytes: 90,
+			allowDownscale: true,
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("reducing dimensions: resize image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockMagickWand{
+				width:  1200,
+				height: 850,
+				blobSize: func(quality, width, height uint) int {
+					return int(quality * width * height / 10000)
+				},
+			}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:                 m,
+				compressionQuality: tc.compressionQuality,
+				maxOutputBytes:     tc.maxOutputBytes,
+				allowDownscale:     tc.allowDownscale,
+			}
+			quality, err := ir.fitOutputSize("JPEG", tc.compressionQuality)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedQuality, quality)
+				require.Equal(t, uint(tc.expectedQuality), m.quality)
+				require.Equal(t, tc.expectedWidth, m.width)
+				require.Equal(t, tc.expectedHeight, m.height)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ssimFromDSSIM converts a structural dissimilarity, as computed by ImageMagick, into the
+// structural similarity index it derives from: DSSIM = (1 - SSIM) / 2.
+func ssimFromDSSIM(dssim float64) float64 {
+	return 1 - 2*dssim
+}
+
+// searchSSIMQuality returns the lowest compression quality, up to highest, whose encoded output
+// keeps an SSIM of at least targetSSIM against the resized but unencoded image, along with the
+// SSIM achieved. The quality is found by binary search, as the SSIM grows with it. If not even
+// highest reaches the target, highest is returned.
+func (i *imageResizer) searchSSIMQuality(format string, highest int) (int, float64, error) {
+	if err := i.mw.SetImageFormat(format); err != nil {
+		return 0, 0, errors.Wrapf(err, "setting image format to %s", format)
+	}
+	if highest <= 0 {
+		highest = maxQuality
+	}
+	found, foundSSIM, reached := highest, 0.0, false
+	low, high := 1, highest
+	for low <= high {
+		quality := (low + high) / 2
+		ssim, err := i.ssimAt(quality)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ssim >= i.targetSSIM {
+			found, foundSSIM, reached, high = quality, ssim, true, quality-1
+		} else {
+			low = quality + 1
+		}
+	}
+	if !reached {
+		ssim, err := i.ssimAt(highest)
+		if err != nil {
+			return 0, 0, err
+		}
+		foundSSIM = ssim
+	}
+	return found, foundSSIM, nil
+}
+
+// ssimAt returns the SSIM between the current image and its output encoded at the given quality.
+func (i *imageResizer) ssimAt(quality int) (float64, error) {
+	blob, err := i.encode(quality)
+	if err != nil {
+		return 0, err
+	}
+	encoded := i.newMagickWand()
+	defer encoded.Destroy()
+	if err := encoded.ReadImageBlob(blob); err != nil {
+		return 0, errors.Wrapf(err, "decoding image encoded at quality %d", quality)
+	}
+	dssim, err := encoded.Distortion(i.mw, metricStructuralDissimilarity)
+	if err != nil {
+		return 0, errors.Wrapf(err, "comparing image encoded at quality %d", quality)
+	}
+	return ssimFromDSSIM(dssim), nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_searchSSIMQuality(t *testing.T) {
+	testCases := []struct {
+		name            string
+		highest         int
+		targetSSIM      float64
+		mockClosure     func(m, encoded *mockMagickWand)
+		expectedQuality int
+		expectedSSIM    float64
+		expectedError   error
+	}{
+		{
+			name:            "lowest quality reaching the target",
+			targetSSIM:      0.949,
+			expectedQuality: 75,
+			expectedSSIM:    0.95,
+		},
+		{
+			name:            "highest quality when none reaches the target",
+			highest:         60,
+			targetSSIM:      0.949,
+			expectedQuality: 60,
+			expectedSSIM:    0.92,
+		},
+		{
+			name:       "error when setting image format",
+			targetSSIM: 0.949,
+			mockClosure: func(m, encoded *mockMagickWand) {
+				m.errSetImageFormat = errors.New("set image format error")
+			},
+			expectedError: errors.New("setting image format to JPEG: set image format error"),
+		},
+		{
+			name:       "error when encoding image",
+			targetSSIM: 0.949,
+			mockClosure: func(m, encoded *mockMagickWand) {
+				m.errGetImageBlob = errors.New("get image blob error")
+			},
+			expectedError: errors.New("encoding image at quality 50: get image blob error"),
+		},
+		{
+			name:       "error when decoding image",
+			targetSSIM: 0.949,
+			mockClosure: func(m, encoded *mockMagickWand) {
+				encoded.errReadImageBlob = errors.New("read image blob error")
+			},
+			expectedError: errors.New("decoding image encoded at quality 50: read image blob error"),
+		},
+		{
+			name:       "error when comparing images",
+			targetSSIM: 0.949,
+			mockClosure: func(m, encoded *mockMagickWand) {
+				encoded.errDistortion = errors.New("distortion error")
+			},
+			expectedError: errors.New("comparing image encoded at quality 50: distortion error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			encoded := &mockMagickWand{dssim: func() float64 { return float64(100-m.quality) / 1000 }}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m, encoded)
+			}
+			ir := &imageResizer{
+				mw:            m,
+				targetSSIM:    tc.targetSSIM,
+				newMagickWand: func() magickWand { return encoded },
+			}
+			quality, ssim, err := ir.searchSSIMQuality("JPEG", tc.highest)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedQuality, quality)
+				require.InDelta(t, tc.expectedSSIM, ssim, 1e-9)
+			}
+		})
+	}
+}
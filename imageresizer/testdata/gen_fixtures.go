@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build ignore
+
+// This program generates the fixture images of the golden-image test suite. They are drawn
+// with the standard library, so that they do not depend on the ImageMagick version installed.
+// Run it from the imageresizer directory with:
+//
+//	go run testdata/gen_fixtures.go
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	width  = 96
+	height = 64
+)
+
+func main() {
+	opaque := pattern(false)
+	translucent := pattern(true)
+	paletted := image.NewPaletted(opaque.Bounds(), palette.Plan9)
+	draw.Draw(paletted, paletted.Bounds(), opaque, image.Point{}, draw.Src)
+	write("pattern.png", func(f *os.File) error { return png.Encode(f, translucent) })
+	write("pattern.jpg", func(f *os.File) error { return jpeg.Encode(f, opaque, &jpeg.Options{Quality: 95}) })
+	write("pattern.gif", func(f *os.File) error { return gif.Encode(f, paletted, nil) })
+}
+
+// pattern draws the fixture image: smooth gradients, which show how filters interpolate, a
+// disc and a checkerboard, which show how they handle edges, and optionally a transparent
+// band with a translucent ramp, which shows how the alpha channel is resampled.
+func pattern(alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: uint8(x * 255 / (width - 1)), G: uint8(y * 255 / (height - 1)), B: 128, A: 255}
+			if dx, dy := x-width/4, y-height/2; dx*dx+dy*dy <= 14*14 {
+				c = color.NRGBA{R: 250, G: 200, B: 20, A: 255}
+			}
+			if x >= width/2 && y >= height/2 && (x/4+y/4)%2 == 0 {
+				c = color.NRGBA{A: 255}
+			}
+			if alpha && y < 8 {
+				c.A = uint8(x * 255 / (width - 1))
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// write creates the fixture named name with the given encoder.
+func write(name string, encode func(f *os.File) error) {
+	f, err := os.Create(filepath.Join("testdata", "fixtures", name))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := encode(f); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build ignore
+
+// This program generates the tricky files seeding the fuzz tests: truncated and corrupted
+// copies of the fixtures, headers declaring huge dimensions, and formats ImageMagick
+// delegates to other coders. Run it from the imageresizer directory, after the fixtures, with:
+//
+//	go run testdata/gen_seeds.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	pngFixture := read("pattern.png")
+	jpgFixture := read("pattern.jpg")
+
+	write("empty", nil)
+	write("not_an_image.txt", []byte("this is not an image\n"))
+
+	write("truncated.png", pngFixture[:60])
+	badCRC := bytes.Clone(pngFixture)
+	badCRC[29] ^= 0xff // Last byte of the CRC of the IHDR chunk.
+	write("bad_crc.png", badCRC)
+	write("huge_dimensions.png", pngHeader(100000, 100000))
+	write("zero_width.png", pngHeader(0, 16))
+
+	write("truncated.jpg", jpgFixture[:len(jpgFixture)/2])
+	write("soi_only.jpg", []byte{0xff, 0xd8})
+
+	write("huge_screen.gif", gifImage(65535, 65535, 1))
+	write("many_frames.gif", gifImage(512, 512, 100))
+	write("no_frames.gif", gifImage(16, 16, 0))
+
+	write("external_reference.svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="16" height="16">
+<image xlink:href="file:///nonexistent/image.png" width="16" height="16"/>
+</svg>
+`))
+	write("external_reference.mvg", []byte(`push graphic-context
+viewbox 0 0 16 16
+image over 0,0 0,0 '/nonexistent/image.png'
+pop graphic-context
+`))
+}
+
+// pngHeader returns a PNG declaring the given dimensions, without any pixel data.
+func pngHeader(width, height uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6 // 8 bits per channel, RGBA.
+	writeChunk(&buf, "IHDR", ihdr)
+	writeChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+// writeChunk appends a PNG chunk of the given type and data to buf.
+func writeChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	buf.WriteString(chunkType)
+	buf.Write(data)
+	binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+// gifImage returns a GIF with a logical screen of the given dimensions and as many black 1x1
+// frames as given.
+func gifImage(width, height uint16, frames int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("GIF89a")
+	binary.Write(&buf, binary.LittleEndian, width)
+	binary.Write(&buf, binary.LittleEndian, height)
+	buf.Write([]byte{0x80, 0, 0})                // Global color table of 2 colors.
+	buf.Write([]byte{0, 0, 0, 0xff, 0xff, 0xff}) // Black and white.
+	for n := 0; n < frames; n++ {
+		buf.Write([]byte{0x2c, 0, 0, 0, 0, 1, 0, 1, 0, 0}) // 1x1 image descriptor at the origin.
+		buf.Write([]byte{2, 2, 0x44, 0x01, 0})             // LZW data of a single black pixel.
+	}
+	buf.WriteByte(0x3b)
+	return buf.Bytes()
+}
+
+// read returns the content of the fixture named name.
+func read(name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return data
+}
+
+// write creates the seed named name with the given content.
+func write(name string, data []byte) {
+	if err := os.WriteFile(filepath.Join("testdata", "seeds", name), data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// defaultTextColor is the fill color used when a TextOverlay does not specify one.
+const defaultTextColor = "white"
+
+// TextOverlay describes a text stamp, such as a copyright notice or a photographer
+// credit, rendered over the resized image.
+type TextOverlay struct {
+	Text             string      // Text to be rendered.
+	FontPath         string      // Path to a TrueType or OpenType font file; empty uses ImageMagick's default font.
+	FontSize         float64     // Font size in points; ignored when RelativeFontSize is set.
+	RelativeFontSize float64     // Font size as a fraction of the output width, e.g. 0.04.
+	Color            string      // Fill color of the text, in any format ImageMagick understands; defaults to white.
+	StrokeColor      string      // Color of the text outline; empty for no outline.
+	StrokeWidth      float64     // Width of the text outline; defaults to 1 when StrokeColor is set.
+	BackgroundColor  string      // Color of the box drawn behind the text; empty for no box.
+	Gravity          GravityType // Where the text is placed; defaults to GRAVITY_SOUTH_EAST.
+	OffsetX          int         // Horizontal distance from the edge the text is attached to.
+	OffsetY          int         // Vertical distance from the edge the text is attached to.
+}
+
+// style resolves the drawing settings of the overlay for an output of the given width.
+func (t *TextOverlay) style(outputWidth int) textStyle {
+	style := textStyle{
+		Font:            t.FontPath,
+		FontSize:        t.FontSize,
+		Color:           t.Color,
+		StrokeColor:     t.StrokeColor,
+		StrokeWidth:     t.StrokeWidth,
+		BackgroundColor: t.BackgroundColor,
+	}
+	if t.RelativeFontSize > 0 {
+		style.FontSize = float64(outputWidth) * t.RelativeFontSize
+	}
+	if style.Color == "" {
+		style.Color = defaultTextColor
+	}
+	if style.StrokeColor != "" && style.StrokeWidth <= 0 {
+		style.StrokeWidth = 1
+	}
+	return style
+}
+
+// applyTextOverlays renders the configured text overlays over the resized image.
+func (i *imageResizer) applyTextOverlays() error {
+	outputWidth, outputHeight := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+	for _, overlay := range i.textOverlays {
+		if overlay.Text == "" {
+			return fmt.Errorf("text overlay must have some text")
+		}
+		style := overlay.style(outputWidth)
+		metrics, err := i.mw.TextMetrics(style, overlay.Text)
+		if err != nil {
+			return errors.Wrapf(err, "measuring text %q", overlay.Text)
+		}
+		gravity := overlay.Gravity
+		if gravity == GRAVITY_UNDEFINED {
+			gravity = GRAVITY_SOUTH_EAST
+		}
+		x, y := gravityPosition(gravity, outputWidth, outputHeight,
+			int(metrics.Width+0.5), int(metrics.Height+0.5), overlay.OffsetX, overlay.OffsetY)
+		// Text is drawn from its baseline, which sits ascender pixels below the top of the box.
+		if err := i.mw.Annotate(style, float64(x), float64(y)+metrics.Ascender, overlay.Text); err != nil {
+			return errors.Wrapf(err, "drawing text %q", overlay.Text)
+		}
+	}
+	return nil
+}
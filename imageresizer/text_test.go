@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextOverlay_style(t *testing.T) {
+	testCases := []struct {
+		name           string
+		overlay        TextOverlay
+		expectedOutput textStyle
+	}{
+		{
+			name:           "defaults",
+			overlay:        TextOverlay{Text: "© ACME 2026"},
+			expectedOutput: textStyle{Color: "white"},
+		},
+		{
+			name: "absolute font size",
+			overlay: TextOverlay{
+				Text:            "© ACME 2026",
+				FontPath:        "path/to/font.ttf",
+				FontSize:        18,
+				Color:           "#ff0000",
+				StrokeColor:     "black",
+				StrokeWidth:     2,
+				BackgroundColor: "rgba(0,0,0,0.5)",
+			},
+			expectedOutput: textStyle{
+				Font:            "path/to/font.ttf",
+				FontSize:        18,
+				Color:           "#ff0000",
+				StrokeColor:     "black",
+				StrokeWidth:     2,
+				BackgroundColor: "rgba(0,0,0,0.5)",
+			},
+		},
+		{
+			name:           "relative font size and default stroke width",
+			overlay:        TextOverlay{Text: "© ACME 2026", FontSize: 18, RelativeFontSize: 0.05, StrokeColor: "black"},
+			expectedOutput: textStyle{FontSize: 60, Color: "white", StrokeColor: "black", StrokeWidth: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := tc.overlay.style(1200)
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func Test_applyTextOverlays(t *testing.T) {
+	testCases := []struct {
+		name                string
+		textOverlays        []TextOverlay
+		mockClosure         func(m *mockMagickWand)
+		expectedAnnotations []annotation
+		expectedError       error
+	}{
+		{
+			name: "no text overlays",
+		},
+		{
+			name: "happy path",
+			textOverlays: []TextOverlay{
+				{Text: "© ACME 2026", OffsetX: 10, OffsetY: 10},
+				{Text: "Photo by Jane", Gravity: GRAVITY_NORTH_WEST, OffsetX: 5, OffsetY: 5},
+			},
+			expectedAnnotations: []annotation{
+				{style: textStyle{Color: "white"}, x: 1090, y: 835, text: "© ACME 2026"},
+				{style: textStyle{Color: "white"}, x: 5, y: 20, text: "Photo by Jane"},
+			},
+		},
+		{
+			name:          "empty text",
+			textOverlays:  []TextOverlay{{}},
+			expectedError: errors.New("text overlay must have some text"),
+		},
+		{
+			name:         "error when measuring text",
+			textOverlays: []TextOverlay{{Text: "© ACME 2026"}},
+			mockClosure: func(m *mockMagickWand) {
+				m.errTextMetrics = errors.New("text metrics error")
+			},
+			expectedError: errors.New(`measuring text "© ACME 2026": text metrics error`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:           m,
+				textOverlays: tc.textOverlays,
+			}
+			err := ir.applyTextOverlays()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedAnnotations, m.annotations)
+			}
+		})
+	}
+}
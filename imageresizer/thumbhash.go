@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// thumbHashMaxSide is the largest side, in pixels, of an image encoded into a ThumbHash.
+// Larger images take longer to encode with no benefit.
+const thumbHashMaxSide = 100
+
+// thumbHash encodes the width x height RGBA pixels into a ThumbHash, following the reference
+// implementation at https://github.com/evanw/thumbhash.
+func thumbHash(width, height int, rgba []byte) ([]byte, error) {
+	if width <= 0 || height <= 0 || width > thumbHashMaxSide || height > thumbHashMaxSide {
+		return nil, fmt.Errorf("%dx%d pixels do not fit in %dx%d", width, height, thumbHashMaxSide, thumbHashMaxSide)
+	}
+	if len(rgba) != width*height*4 {
+		return nil, fmt.Errorf("invalid %dx%d pixels", width, height)
+	}
+	pixels := width * height
+
+	// Determine the average color.
+	var avgR, avgG, avgB, avgA float64
+	for n := 0; n < pixels; n++ {
+		alpha := float64(rgba[n*4+3]) / 255
+		avgR += alpha / 255 * float64(rgba[n*4])
+		avgG += alpha / 255 * float64(rgba[n*4+1])
+		avgB += alpha / 255 * float64(rgba[n*4+2])
+		avgA += alpha
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(pixels)
+	lLimit := 7.0
+	if hasAlpha {
+		lLimit = 5 // Use fewer luminance bits if there is alpha.
+	}
+	longest := float64(max(width, height))
+	lx := max(1, roundHalfUp(lLimit*float64(width)/longest))
+	ly := max(1, roundHalfUp(lLimit*float64(height)/longest))
+
+	// Convert the image from RGBA to LPQA, composited atop the average color.
+	l := make([]float64, pixels) // Luminance.
+	p := make([]float64, pixels) // Yellow - blue.
+	q := make([]float64, pixels) // Red - green.
+	a := make([]float64, pixels) // Alpha.
+	for n := 0; n < pixels; n++ {
+		alpha := float64(rgba[n*4+3]) / 255
+		r := avgR*(1-alpha) + alpha/255*float64(rgba[n*4])
+		g := avgG*(1-alpha) + alpha/255*float64(rgba[n*4+1])
+		b := avgB*(1-alpha) + alpha/255*float64(rgba[n*4+2])
+		l[n] = (r + g + b) / 3
+		p[n] = (r+g)/2 - b
+		q[n] = r - g
+		a[n] = alpha
+	}
+
+	// Encode using the DCT into DC (constant) and normalized AC (varying) terms.
+	lDC, lAC, lScale := thumbHashChannel(l, width, height, max(3, lx), max(3, ly))
+	pDC, pAC, pScale := thumbHashChannel(p, width, height, 3, 3)
+	qDC, qAC, qScale := thumbHashChannel(q, width, height, 3, 3)
+	var aDC, aScale float64
+	var aAC []float64
+	if hasAlpha {
+		aDC, aAC, aScale = thumbHashChannel(a, width, height, 5, 5)
+	}
+
+	// Write the constants.
+	isLandscape := width > height
+	header24 := roundHalfUp(63*lDC) | roundHalfUp(31.5+31.5*pDC)<<6 | roundHalfUp(31.5+31.5*qDC)<<12 | roundHalfUp(31*lScale)<<18
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+	header16 := lx
+	if isLandscape {
+		header16 = ly
+	}
+	header16 |= roundHalfUp(63*pScale)<<3 | roundHalfUp(63*qScale)<<9
+	if isLandscape {
+		header16 |= 1 << 15
+	}
+	hash := []byte{byte(header24), byte(header24 >> 8), byte(header24 >> 16), byte(header16), byte(header16 >> 8)}
+	if hasAlpha {
+		hash = append(hash, byte(roundHalfUp(15*aDC)|roundHalfUp(15*aScale)<<4))
+	}
+
+	// Write the varying factors, two per byte.
+	acs := [][]float64{lAC, pAC, qAC}
+	if hasAlpha {
+		acs = append(acs, aAC)
+	}
+	acStart, acIndex := len(hash), 0
+	for _, ac := range acs {
+		for _, f := range ac {
+			if acIndex%2 == 0 {
+				hash = append(hash, 0)
+			}
+			hash[acStart+acIndex/2] |= byte(roundHalfUp(15*f) << ((acIndex & 1) * 4))
+			acIndex++
+		}
+	}
+	return hash, nil
+}
+
+// thumbHashChannel returns the DC term, the AC terms normalized between 0 and 1, and the scale
+// of the AC terms of the DCT of a width x height channel, using nx x ny frequencies.
+func thumbHashChannel(channel []float64, width, height, nx, ny int) (float64, []float64, float64) {
+	var dc, scale float64
+	var ac []float64
+	fx := make([]float64, width)
+	for cy := 0; cy < ny; cy++ {
+		for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+			for x := 0; x < width; x++ {
+				fx[x] = math.Cos(math.Pi / float64(width) * float64(cx) * (float64(x) + 0.5))
+			}
+			f := 0.0
+			for y := 0; y < height; y++ {
+				fy := math.Cos(math.Pi / float64(height) * float64(cy) * (float64(y) + 0.5))
+				for x := 0; x < width; x++ {
+					f += channel[x+y*width] * fx[x] * fy
+				}
+			}
+			f /= float64(width * height)
+			if cx > 0 || cy > 0 {
+				ac = append(ac, f)
+				scale = math.Max(scale, math.Abs(f))
+			} else {
+				dc = f
+			}
+		}
+	}
+	if scale > 0 {
+		for n := range ac {
+			ac[n] = 0.5 + 0.5/scale*ac[n]
+		}
+	}
+	return dc, ac, scale
+}
+
+// roundHalfUp rounds value to the nearest integer, halves rounding up, as JavaScript does.
+func roundHalfUp(value float64) int {
+	return int(math.Floor(value + 0.5))
+}
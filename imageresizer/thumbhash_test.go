@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_thumbHash(t *testing.T) {
+	testCases := []struct {
+		name          string
+		width         int
+		height        int
+		rgba          []byte
+		expectedLen   int
+		expectedLDC   int
+		expectedAlpha bool
+		expectedError error
+	}{
+		{
+			name:        "opaque square",
+			width:       8,
+			height:      8,
+			rgba:        solidRGBA(8, 8, [4]byte{255, 255, 255, 255}),
+			expectedLen: 5 + (27+5+5+1)/2,
+			expectedLDC: 63,
+		},
+		{
+			name:        "opaque landscape",
+			width:       100,
+			height:      50,
+			rgba:        solidRGBA(100, 50, [4]byte{0, 0, 0, 255}),
+			expectedLen: 5 + (18+5+5+1)/2,
+			expectedLDC: 0,
+		},
+		{
+			name:          "translucent square",
+			width:         4,
+			height:        4,
+			rgba:          solidRGBA(4, 4, [4]byte{255, 255, 255, 128}),
+			expectedLen:   6 + (14+5+5+14+1)/2,
+			expectedLDC:   63,
+			expectedAlpha: true,
+		},
+		{
+			name:          "too large",
+			width:         101,
+			height:        10,
+			rgba:          solidRGBA(101, 10, [4]byte{}),
+			expectedError: errors.New("101x10 pixels do not fit in 100x100"),
+		},
+		{
+			name:          "missing pixels",
+			width:         4,
+			height:        4,
+			rgba:          solidRGBA(4, 3, [4]byte{}),
+			expectedError: errors.New("invalid 4x4 pixels"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := thumbHash(tc.width, tc.height, tc.rgba)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Len(t, hash, tc.expectedLen)
+				header24 := int(hash[0]) | int(hash[1])<<8 | int(hash[2])<<16
+				require.Equal(t, tc.expectedLDC, header24&63, "luminance DC")
+				require.Equal(t, 32, header24>>6&63, "yellow-blue DC of a gray")
+				require.Equal(t, 32, header24>>12&63, "red-green DC of a gray")
+				require.Equal(t, tc.expectedAlpha, header24>>23 == 1, "alpha flag")
+				header16 := int(hash[3]) | int(hash[4])<<8
+				require.Equal(t, tc.width > tc.height, header16>>15 == 1, "landscape flag")
+			}
+		})
+	}
+}
+
+func Test_thumbHashPattern(t *testing.T) {
+	pattern := func(width, height int, alpha func(x, y int) byte) []byte {
+		return rgbaOf(width, height, func(x, y int) [4]byte {
+			return [4]byte{byte(x*37 + y*11), byte(x*x*5 + y*23), byte(x*y*13 + 50), alpha(x, y)}
+		})
+	}
+	hash, err := thumbHash(16, 12, pattern(16, 12, func(x, y int) byte { return 255 }))
+	require.NoError(t, err)
+	require.Equal(t, "HvgFDYI2B5VENbRVd2qHaH8YuKC/", base64.StdEncoding.EncodeToString(hash))
+	hash, err = thumbHash(10, 20, pattern(10, 20, func(x, y int) byte { return byte(x*25 + y*3) }))
+	require.NoError(t, err)
+	require.Equal(t, "HwiCCwQomNKANWjbho8rb1BpZ2mHd3g=", base64.StdEncoding.EncodeToString(hash))
+}
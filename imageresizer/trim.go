@@ -0,0 +1,48 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Trim describes how uniform borders are removed from an image before it is resized.
+type Trim struct {
+	Fuzz    float64 // Tolerance, from 0 to 1, under which a color is considered the same as the border color.
+	Color   string  // Color of the borders to remove; empty detects it from the image corners.
+	Padding float64 // Uniform padding re-added around the trimmed image, as a percentage of its larger side.
+}
+
+// paddingSize returns the number of pixels added to each side of a trimmed image of the given dimensions.
+func (t *Trim) paddingSize(width, height int) int {
+	if t.Padding <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(max(width, height)) * t.Padding / 100))
+}
+
+// trim removes the uniform borders of the image and re-adds the configured padding, so that
+// the target dimensions are computed from the trimmed image. Multi-frame images are not
+// trimmed, as each frame would end up with a different size.
+func (i *imageResizer) trim() error {
+	if i.trimming == nil || i.mw.GetNumberImages() > 1 {
+		return nil
+	}
+	border, err := i.mw.TrimBorders(i.trimming.Color, i.trimming.Fuzz)
+	if err != nil {
+		return err
+	}
+	width, height := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+	padding := i.trimming.paddingSize(width, height)
+	if padding == 0 {
+		return nil
+	}
+	if err := i.mw.Extend(uint(width+2*padding), uint(height+2*padding), padding, padding, border); err != nil {
+		return errors.Wrap(err, "adding padding")
+	}
+	return nil
+}
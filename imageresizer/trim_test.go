@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trim(t *testing.T) {
+	testCases := []struct {
+		name            string
+		trimming        *Trim
+		mockClosure     func(m *mockMagickWand)
+		expectedExtents []extent
+		expectedError   error
+	}{
+		{
+			name: "no trimming",
+		},
+		{
+			name:     "without padding",
+			trimming: &Trim{Fuzz: 0.1},
+		},
+		{
+			name:            "with padding in the detected color",
+			trimming:        &Trim{Fuzz: 0.1, Padding: 5},
+			expectedExtents: []extent{{1320, 970, 60, 60, "srgb(255,255,255)"}},
+		},
+		{
+			name:            "with padding in the given color",
+			trimming:        &Trim{Color: "#f0f0f0", Padding: 5},
+			expectedExtents: []extent{{1320, 970, 60, 60, "#f0f0f0"}},
+		},
+		{
+			name:     "error when adding padding",
+			trimming: &Trim{Padding: 5},
+			mockClosure: func(m *mockMagickWand) {
+				m.errExtend = errors.New("extend error")
+			},
+			expectedError: errors.New("adding padding: extend error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			ir := &imageResizer{
+				mw:       m,
+				trimming: tc.trimming,
+			}
+			err := ir.trim()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedExtents, m.extents)
+			}
+		})
+	}
+}
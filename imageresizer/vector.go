@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"math"
+	"strings"
+)
+
+// defaultDensity is the resolution, in DPI, ImageMagick assumes for images that do not carry one.
+const defaultDensity = 72
+
+// vectorFormats lists the formats that ImageMagick rasterizes at the resolution set before reading.
+var vectorFormats = map[string]bool{
+	"SVG":  true,
+	"SVGZ": true,
+	"MSVG": true,
+	"PDF":  true,
+	"EPS":  true,
+	"PS":   true,
+	"AI":   true,
+	"WMF":  true,
+}
+
+// isVectorFormat reports whether images of the given format are rasterized when read.
+func isVectorFormat(format string) bool {
+	return vectorFormats[strings.ToUpper(format)]
+}
+
+// vectorDensity returns the resolution at which a vector image, whose size is width x height
+// when rasterized at the given density, must be rasterized to cover targetWidth x targetHeight.
+func vectorDensity(density float64, width, height, targetWidth, targetHeight int) float64 {
+	if density <= 0 {
+		density = defaultDensity
+	}
+	if width <= 0 || height <= 0 {
+		return density
+	}
+	scale := math.Max(float64(targetWidth)/float64(width), float64(targetHeight)/float64(height))
+	return math.Ceil(density * scale)
+}
+
+// readDensity returns the resolution at which the image described by info must be read.
+// An explicit density always wins. Otherwise, vector images are rasterized directly at the size
+// set by WithDimensions, rather than at the default density and then scaled as a blurry bitmap.
+// Zero means the default, which is also used when no dimensions are set.
+func (i *imageResizer) readDensity(info ImageInfo) float64 {
+	if i.density > 0 || !isVectorFormat(info.Format) || i.newWidth == nil || i.newHeight == nil {
+		return i.density
+	}
+	return vectorDensity(info.Resolution, info.Width, info.Height, *i.newWidth, *i.newHeight)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_vectorDensity(t *testing.T) {
+	testCases := []struct {
+		name           string
+		density        float64
+		width, height  int
+		expectedOutput float64
+	}{
+		{
+			name:           "default density",
+			width:          300,
+			height:         150,
+			expectedOutput: 384,
+		},
+		{
+			name:           "given density",
+			density:        96,
+			width:          300,
+			height:         150,
+			expectedOutput: 512,
+		},
+		{
+			name:           "width drives the density",
+			width:          300,
+			height:         300,
+			expectedOutput: 288,
+		},
+		{
+			name:           "unknown size",
+			density:        96,
+			expectedOutput: 96,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := vectorDensity(tc.density, tc.width, tc.height, 1200, 800)
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func Test_readDensity(t *testing.T) {
+	testCases := []struct {
+		name           string
+		density        float64
+		newWidth       *int
+		newHeight      *int
+		info           ImageInfo
+		expectedOutput float64
+	}{
+		{
+			name:           "explicit density",
+			density:        300,
+			newWidth:       IntPtr(2400),
+			newHeight:      IntPtr(1700),
+			info:           ImageInfo{Format: "SVG", Width: 1200, Height: 850, Resolution: 96},
+			expectedOutput: 300,
+		},
+		{
+			name: "no dimensions",
+		},
+		{
+			name:      "raster image",
+			newWidth:  IntPtr(2400),
+			newHeight: IntPtr(1700),
+			info:      ImageInfo{Format: "JPEG", Width: 1200, Height: 850},
+		},
+		{
+			name:           "vector image",
+			newWidth:       IntPtr(2400),
+			newHeight:      IntPtr(1700),
+			info:           ImageInfo{Format: "SVG", Width: 1200, Height: 850, Resolution: 96},
+			expectedOutput: 192,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := &imageResizer{
+				density:   tc.density,
+				newWidth:  tc.newWidth,
+				newHeight: tc.newHeight,
+			}
+			require.Equal(t, tc.expectedOutput, ir.readDensity(tc.info))
+		})
+	}
+}
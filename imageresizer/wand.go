@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import "github.com/tiagomelo/go-image-resizer/imageresizer/internal/wand"
+
+// magickWand defines an interface for working with ImageMagick's MagickWand API. It is
+// implemented by magickWandWrapper, when the package is built with ImageMagick, and in memory
+// by the wands of WithMemoryBackend. It is defined, along with the types its methods take, in
+// the internal wand package, which the in-memory wands are part of.
+type magickWand = wand.Wand
+
+// The types of the magickWand methods, under the names this package uses.
+type (
+	interlaceType     = wand.InterlaceType     // Interlace scheme images are encoded with.
+	alphaChannelType  = wand.AlphaChannelType  // Operation on the alpha channel of an image.
+	channelType       = wand.ChannelType       // Set of channels operations are restricted to.
+	evaluateOperator  = wand.EvaluateOperator  // Arithmetic operator applied to the channels of an image.
+	compositeOperator = wand.CompositeOperator // Way an image is drawn over another.
+	metricType        = wand.MetricType        // Metric measuring how much an image differs from a reference.
+	textStyle         = wand.TextStyle         // Resolved drawing settings used to render a TextOverlay.
+	textMetrics       = wand.TextMetrics       // Dimensions of a line of text, in pixels.
+	histogramColor    = wand.HistogramColor    // Distinct color of an image, with its number of pixels.
+)
+
+const (
+	interlaceUndefined            = wand.InterlaceUndefined
+	interlacePlane                = wand.InterlacePlane
+	alphaChannelSet               = wand.AlphaChannelSet
+	channelsDefault               = wand.ChannelsDefault
+	channelAlpha                  = wand.ChannelAlpha
+	evaluateMultiply              = wand.EvaluateMultiply
+	compositeOver                 = wand.CompositeOver
+	compositeDstOver              = wand.CompositeDstOver
+	metricAbsoluteError           = wand.MetricAbsoluteError
+	metricRootMeanSquaredError    = wand.MetricRootMeanSquaredError
+	metricPeakSignalToNoiseRatio  = wand.MetricPeakSignalToNoiseRatio
+	metricStructuralDissimilarity = wand.MetricStructuralDissimilarity
+)
+
+// wandFilter returns the filter as magickWand methods take it.
+func (f FilterType) wandFilter() wand.Filter {
+	return wand.Filter(f)
+}
+
+// unreadableWand is a wand that fails to read any image with err, so that the other methods,
+// of the embedded wand, have none to work on.
+type unreadableWand struct {
+	wand.Wand
+	err error
+}
+
+func (mw unreadableWand) ReadImage(filename string) error { return mw.err }
+func (mw unreadableWand) ReadImageBlob(blob []byte) error { return mw.err }
+func (mw unreadableWand) PingImage(filename string) error { return mw.err }
+func (mw unreadableWand) PingImageBlob(blob []byte) error { return mw.err }
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// maxWatermarkTiles is the largest number of times a tiled watermark is drawn over an image.
+const maxWatermarkTiles = 10000
+
+// Watermark describes an image composited over the resized image.
+//
+// Opacity is applied only between 0 and 1, exclusive: its zero value, as well as 1, leaves the
+// watermark fully opaque, so that a Watermark without Opacity set is visible. A nearly
+// invisible watermark needs a small positive Opacity, such as 0.05.
+type Watermark struct {
+	Path    string      // Path to the watermark image; ignored when Reader is set.
+	Reader  io.Reader   // Reader providing the watermark image; consumed on the first resize.
+	Gravity GravityType // Where the watermark is placed; defaults to GRAVITY_SOUTH_EAST.
+	OffsetX int         // Horizontal distance from the edge the watermark is attached to. Spacing between tiles in tiled mode, which may be negative but must leave tiles advancing.
+	OffsetY int         // Vertical distance from the edge the watermark is attached to. Spacing between tiles in tiled mode, which may be negative but must leave tiles advancing.
+	Opacity float64     // Opacity of the watermark, from 0 to 1. ZERO MEANS FULLY OPAQUE, not invisible.
+	Scale   float64     // Width of the watermark relative to the output width; zero keeps its original size.
+	Tiled   bool        // Whether the watermark is repeated across the whole image instead of placed once.
+}
+
+// blob returns the encoded watermark image, read from its reader or from its path.
+func (w *Watermark) blob() ([]byte, error) {
+	if w.Reader != nil {
+		return io.ReadAll(w.Reader)
+	}
+	if w.Path == "" {
+		return nil, fmt.Errorf("watermark path or reader must be set")
+	}
+	return os.ReadFile(w.Path)
+}
+
+// size returns the dimensions of the watermark once scaled relative to the output width,
+// preserving its aspect ratio.
+func (w *Watermark) size(outputWidth, width, height int) (int, int) {
+	if w.Scale <= 0 || width <= 0 {
+		return width, height
+	}
+	newWidth := int(float64(outputWidth)*w.Scale + 0.5)
+	newHeight := int(float64(height)*float64(newWidth)/float64(width) + 0.5)
+	return max(newWidth, 1), max(newHeight, 1)
+}
+
+// validate returns an error if the opacity of the watermark is outside of 0 to 1.
+func (w *Watermark) validate() error {
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("watermark opacity %g is outside of 0 to 1", w.Opacity)
+	}
+	return nil
+}
+
+// positions returns the top left positions where the watermark is drawn over the output. In
+// tiled mode, it returns an error if the offsets keep the tiles from advancing or if more than
+// maxWatermarkTiles would be drawn.
+func (w *Watermark) positions(outputWidth, outputHeight, width, height int) ([][2]int, error) {
+	if !w.Tiled {
+		gravity := w.Gravity
+		if gravity == GRAVITY_UNDEFINED {
+			gravity = GRAVITY_SOUTH_EAST
+		}
+		x, y := gravityPosition(gravity, outputWidth, outputHeight, width, height, w.OffsetX, w.OffsetY)
+		return [][2]int{{x, y}}, nil
+	}
+	if width <= 0 || height <= 0 {
+		return nil, nil
+	}
+	// Negative offsets overlap the tiles, but must leave them advancing.
+	stepX, stepY := width+w.OffsetX, height+w.OffsetY
+	if stepX <= 0 || stepY <= 0 {
+		return nil, fmt.Errorf("watermark offsets %d,%d keep %dx%d tiles from advancing", w.OffsetX, w.OffsetY, width, height)
+	}
+	columns, rows := (outputWidth+stepX-1)/stepX, (outputHeight+stepY-1)/stepY
+	if int64(columns)*int64(rows) > maxWatermarkTiles {
+		return nil, fmt.Errorf("tiling the watermark takes %dx%d tiles, more than %d", columns, rows, maxWatermarkTiles)
+	}
+	positions := make([][2]int, 0, columns*rows)
+	for y := 0; y < outputHeight; y += stepY {
+		for x := 0; x < outputWidth; x += stepX {
+			positions = append(positions, [2]int{x, y})
+		}
+	}
+	return positions, nil
+}
+
+// applyWatermark composites the configured watermark over the resized image.
+func (i *imageResizer) applyWatermark() error {
+	if i.watermark == nil {
+		return nil
+	}
+	if err := i.watermark.validate(); err != nil {
+		return err
+	}
+	if i.watermarkBlob == nil {
+		blob, err := i.watermark.blob()
+		if err != nil {
+			return errors.Wrap(err, "loading watermark")
+		}
+		i.watermarkBlob = blob
+	}
+	wm := i.newMagickWand()
+	defer wm.Destroy()
+	if err := wm.ReadImageBlob(i.watermarkBlob); err != nil {
+		return errors.Wrap(err, "reading watermark")
+	}
+	outputWidth, outputHeight := int(i.mw.GetImageWidth()), int(i.mw.GetImageHeight())
+	width, height := i.watermark.size(outputWidth, int(wm.GetImageWidth()), int(wm.GetImageHeight()))
+	if width != int(wm.GetImageWidth()) || height != int(wm.GetImageHeight()) {
+		if err := wm.ResizeImage(uint(width), uint(height), i.filterType.wandFilter()); err != nil {
+			return errors.Wrap(err, "resizing watermark")
+		}
+	}
+	if opacity := i.watermark.Opacity; opacity > 0 && opacity < 1 {
+		if err := setOpacity(wm, opacity); err != nil {
+			return errors.Wrapf(err, "setting watermark opacity to %g", opacity)
+		}
+	}
+	positions, err := i.watermark.positions(outputWidth, outputHeight, width, height)
+	if err != nil {
+		return err
+	}
+	for _, position := range positions {
+		if err := i.mw.Composite(wm, compositeOver, position[0], position[1]); err != nil {
+			return errors.Wrap(err, "compositing watermark")
+		}
+	}
+	return nil
+}
+
+// setOpacity multiplies the alpha channel of the image by opacity, adding an opaque
+// alpha channel first if the image has none.
+func setOpacity(mw magickWand, opacity float64) error {
+	if !mw.GetImageAlphaChannel() {
+		if err := mw.SetImageAlphaChannel(alphaChannelSet); err != nil {
+			return err
+		}
+	}
+	previousMask := mw.SetImageChannelMask(channelAlpha)
+	defer mw.SetImageChannelMask(previousMask)
+	return mw.EvaluateImage(evaluateMultiply, opacity)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatermark_size(t *testing.T) {
+	testCases := []struct {
+		name           string
+		scale          float64
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{
+			name:           "no scale",
+			expectedWidth:  200,
+			expectedHeight: 100,
+		},
+		{
+			name:           "quarter of the output width",
+			scale:          0.25,
+			expectedWidth:  300,
+			expectedHeight: 150,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Watermark{Scale: tc.scale}
+			width, height := w.size(1200, 200, 100)
+			require.Equal(t, tc.expectedWidth, width)
+			require.Equal(t, tc.expectedHeight, height)
+		})
+	}
+}
+
+func TestWatermark_positions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		watermark      Watermark
+		expectedOutput [][2]int
+		expectedError  error
+	}{
+		{
+			name:           "default gravity",
+			watermark:      Watermark{OffsetX: 10, OffsetY: 20},
+			expectedOutput: [][2]int{{390, 180}},
+		},
+		{
+			name:           "center gravity",
+			watermark:      Watermark{Gravity: GRAVITY_CENTER},
+			expectedOutput: [][2]int{{200, 100}},
+		},
+		{
+			name:      "tiled",
+			watermark: Watermark{Tiled: true, OffsetX: 100, OffsetY: 50},
+			expectedOutput: [][2]int{
+				{0, 0}, {300, 0},
+				{0, 150}, {300, 150},
+			},
+		},
+		{
+			name:      "tiled with overlapping offsets",
+			watermark: Watermark{Tiled: true, OffsetX: -100, OffsetY: -50},
+			expectedOutput: [][2]int{
+				{0, 0}, {100, 0}, {200, 0}, {300, 0}, {400, 0}, {500, 0},
+				{0, 50}, {100, 50}, {200, 50}, {300, 50}, {400, 50}, {500, 50},
+				{0, 100}, {100, 100}, {200, 100}, {300, 100}, {400, 100}, {500, 100},
+				{0, 150}, {100, 150}, {200, 150}, {300, 150}, {400, 150}, {500, 150},
+				{0, 200}, {100, 200}, {200, 200}, {300, 200}, {400, 200}, {500, 200},
+				{0, 250}, {100, 250}, {200, 250}, {300, 250}, {400, 250}, {500, 250},
+			},
+		},
+		{
+			name:          "tiled with offsets cancelling the watermark size",
+			watermark:     Watermark{Tiled: true, OffsetX: -200, OffsetY: -150},
+			expectedError: errors.New("watermark offsets -200,-150 keep 200x100 tiles from advancing"),
+		},
+		{
+			name:          "tiled with too many tiles",
+			watermark:     Watermark{Tiled: true, OffsetX: -199, OffsetY: -99},
+			expectedError: errors.New("tiling the watermark takes 600x300 tiles, more than 10000"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := tc.watermark.positions(600, 300, 200, 100)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func Test_applyWatermark(t *testing.T) {
+	testCases := []struct {
+		name          string
+		watermark     *Watermark
+		mockClosure   func(m *mockMagickWand)
+		expectedError error
+	}{
+		{
+			name: "no watermark",
+		},
+		{
+			name:      "happy path",
+			watermark: &Watermark{Reader: strings.NewReader("watermark"), Opacity: 0.5, Scale: 0.2, Tiled: true},
+		},
+		{
+			name:          "opacity out of range",
+			watermark:     &Watermark{Reader: strings.NewReader("watermark"), Opacity: 1.5},
+			expectedError: errors.New("watermark opacity 1.5 is outside of 0 to 1"),
+		},
+		{
+			name:          "offsets keeping tiles from advancing",
+			watermark:     &Watermark{Reader: strings.NewReader("watermark"), Tiled: true, OffsetX: -5000},
+			expectedError: errors.New("watermark offsets -5000,0 keep 1200x850 tiles from advancing"),
+		},
+		{
+			name:          "no path nor reader",
+			watermark:     &Watermark{},
+			expectedError: errors.New("loading watermark: watermark path or reader must be set"),
+		},
+		{
+			name:      "error when reading watermark",
+			watermark: &Watermark{Reader: strings.NewReader("watermark")},
+			mockClosure: func(m *mockMagickWand) {
+				m.errReadImageBlob = errors.New("read image blob error")
+			},
+			expectedError: errors.New("reading watermark: read image blob error"),
+		},
+		{
+			name:      "error when resizing watermark",
+			watermark: &Watermark{Reader: strings.NewReader("watermark"), Scale: 0.2},
+			mockClosure: func(m *mockMagickWand) {
+				m.errResizeImage = errors.New("resize image error")
+			},
+			expectedError: errors.New("resizing watermark: resize image error"),
+		},
+		{
+			name:      "error when setting watermark opacity",
+			watermark: &Watermark{Reader: strings.NewReader("watermark"), Opacity: 0.5},
+			mockClosure: func(m *mockMagickWand) {
+				m.errEvaluateImage = errors.New("evaluate image error")
+			},
+			expectedError: errors.New("setting watermark opacity to 0.5: evaluate image error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wm := new(mockMagickWand)
+			if tc.mockClosure != nil {
+				tc.mockClosure(wm)
+			}
+			ir := &imageResizer{
+				mw:            new(mockMagickWand),
+				watermark:     tc.watermark,
+				newMagickWand: func() magickWand { return wm },
+			}
+			err := ir.applyWatermark()
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
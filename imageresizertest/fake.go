@@ -0,0 +1,316 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package imageresizertest provides test doubles for code that depends on the imageresizer
+// package: Fake, a configurable implementation of its interfaces that records its calls and
+// writes deterministic placeholder files, and NewInMemory, a real resizer processing images in
+// memory.
+//
+// Neither of them calls ImageMagick, so tests using them build and run without its libraries,
+// e.g. with CGO_ENABLED=0 or the nomagick build tag.
+package imageresizertest
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/go-image-resizer/imageresizer"
+)
+
+// defaultSize is the width and height of the images the Fake writes and reports when none is set.
+const defaultSize = 16
+
+// Stage is a step of the operations of a Fake, at which an error can be injected.
+type Stage string
+
+const (
+	StageRead        Stage = "read"        // Reading the input image, for every method but Compare.
+	StageResize      Stage = "resize"      // Resizing the image, for Resize, ResizeWithResult and ResizePages.
+	StageWrite       Stage = "write"       // Writing the output, for Resize, ResizeWithResult and ResizePages.
+	StagePlaceholder Stage = "placeholder" // Computing placeholders, for Placeholder and ResizeWithResult.
+	StageColors      Stage = "colors"      // Analyzing colors, for Colors and ResizeWithResult.
+	StageHash        Stage = "hash"        // Computing a perceptual hash, for PerceptualHash.
+	StageCompare     Stage = "compare"     // Comparing images, for Compare.
+)
+
+// Call is a call made to a Fake.
+type Call struct {
+	Method string   // Name of the method called, e.g. "Resize".
+	Args   []string // Image file paths the method was called with; empty for readers.
+}
+
+// Fake implements imageresizer.ImageResizer, along with the other interfaces of the imageresizer
+// package, for tests. Instead of resizing images, it writes
+// placeholder files with the output paths a real resizer would use, and returns the results
+// it is configured with. It records every call and fails at the stages given in Errors, with
+// messages like those of a real resizer. Its fields must be set before it is used; it is then
+// safe for concurrent use.
+type Fake struct {
+	Width        int    // Width of the files written and of the images inspected; defaults to 16.
+	Height       int    // Height of the files written and of the images inspected; defaults to 16.
+	OutputDir    string // Directory outputs are written to; empty for the directory of their input.
+	OutputFormat string // Format of the outputs, e.g. "png"; empty to keep the format of their input.
+	Quality      int    // Compression quality reported by ResizeWithResult.
+	Pages        int    // Number of pages written by ResizePages; defaults to 1.
+
+	Info              *imageresizer.ImageInfo  // Attributes returned by Inspect; nil to derive them from the input.
+	PlaceholderResult imageresizer.Placeholder // Placeholders returned by Placeholder and ResizeWithResult.
+	ColorsResult      imageresizer.Colors      // Colors returned by Colors and ResizeWithResult.
+	Hashes            map[string]uint64        // Perceptual hash of each image file path; others hash to 0.
+	Comparison        imageresizer.Comparison  // Comparison returned by Compare.
+
+	Errors map[Stage]error // Errors injected at each stage.
+
+	mu        sync.Mutex
+	calls     []Call
+	destroyed bool
+}
+
+var (
+	_ imageresizer.ImageResizer         = (*Fake)(nil)
+	_ imageresizer.ResultResizer        = (*Fake)(nil)
+	_ imageresizer.PageResizer          = (*Fake)(nil)
+	_ imageresizer.Inspector            = (*Fake)(nil)
+	_ imageresizer.PlaceholderGenerator = (*Fake)(nil)
+	_ imageresizer.ColorAnalyzer        = (*Fake)(nil)
+	_ imageresizer.PerceptualHasher     = (*Fake)(nil)
+	_ imageresizer.Comparer             = (*Fake)(nil)
+)
+
+// Calls returns the calls made to the fake so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Destroyed reports whether Destroy was called.
+func (f *Fake) Destroyed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.destroyed
+}
+
+// record records a call to method with the given image file paths.
+func (f *Fake) record(method string, args ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+// fail returns the error injected at stage, wrapped with the given message, if any.
+func (f *Fake) fail(stage Stage, format string, args ...any) error {
+	if err := f.Errors[stage]; err != nil {
+		return errors.Wrapf(err, format, args...)
+	}
+	return nil
+}
+
+// Resize writes a placeholder output for imageFilePath and returns its path.
+func (f *Fake) Resize(imageFilePath string) (string, error) {
+	f.record("Resize", imageFilePath)
+	result, err := f.resize(imageFilePath)
+	return result.Path, err
+}
+
+// ResizeWithResult writes a placeholder output for imageFilePath and returns its path, along
+// with the configured quality, placeholders and colors.
+func (f *Fake) ResizeWithResult(imageFilePath string) (imageresizer.Result, error) {
+	f.record("ResizeWithResult", imageFilePath)
+	return f.resize(imageFilePath)
+}
+
+// resize writes the placeholder output of imageFilePath and describes it.
+func (f *Fake) resize(imageFilePath string) (imageresizer.Result, error) {
+	if err := f.fail(StageRead, "reading image %s", imageFilePath); err != nil {
+		return imageresizer.Result{}, err
+	}
+	if err := f.fail(StageResize, "resizing image"); err != nil {
+		return imageresizer.Result{}, err
+	}
+	if err := f.fail(StagePlaceholder, "computing placeholder"); err != nil {
+		return imageresizer.Result{}, err
+	}
+	if err := f.fail(StageColors, "analyzing colors"); err != nil {
+		return imageresizer.Result{}, err
+	}
+	resizedImageFilePath := imageresizer.ResizedImageFilePath(imageFilePath, f.OutputDir, f.OutputFormat)
+	if err := f.write(resizedImageFilePath); err != nil {
+		return imageresizer.Result{}, err
+	}
+	return imageresizer.Result{
+		Path:        resizedImageFilePath,
+		Quality:     f.Quality,
+		Placeholder: f.PlaceholderResult,
+		Colors:      f.ColorsResult,
+	}, nil
+}
+
+// ResizePages writes a placeholder output for each of the configured number of pages of
+// imageFilePath and returns their paths.
+func (f *Fake) ResizePages(imageFilePath string) ([]string, error) {
+	f.record("ResizePages", imageFilePath)
+	if err := f.fail(StageRead, "reading image %s", imageFilePath); err != nil {
+		return nil, err
+	}
+	pages := f.Pages
+	if pages == 0 {
+		pages = 1
+	}
+	resizedImageFilePath := imageresizer.ResizedImageFilePath(imageFilePath, f.OutputDir, f.OutputFormat)
+	resizedImageFilePaths := make([]string, 0, pages)
+	for page := 1; page <= pages; page++ {
+		if err := f.fail(StageResize, "resizing page %d", page); err != nil {
+			return nil, err
+		}
+		pageFilePath := imageresizer.PageFilePath(resizedImageFilePath, page)
+		if err := f.write(pageFilePath); err != nil {
+			return nil, err
+		}
+		resizedImageFilePaths = append(resizedImageFilePaths, pageFilePath)
+	}
+	return resizedImageFilePaths, nil
+}
+
+// Inspect returns the configured attributes or, if none are, those of an image of the fake's
+// dimensions in the format following the extension of imageFilePath, which is not read.
+func (f *Fake) Inspect(imageFilePath string) (imageresizer.ImageInfo, error) {
+	f.record("Inspect", imageFilePath)
+	if err := f.fail(StageRead, "pinging image %s", imageFilePath); err != nil {
+		return imageresizer.ImageInfo{}, err
+	}
+	return f.info(imageresizer.FormatOf(imageFilePath)), nil
+}
+
+// InspectReader consumes r and returns the configured attributes or, if none are, those of an
+// image of the fake's dimensions in an unknown format.
+func (f *Fake) InspectReader(r io.Reader) (imageresizer.ImageInfo, error) {
+	f.record("InspectReader")
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return imageresizer.ImageInfo{}, errors.Wrap(err, "reading image")
+	}
+	if err := f.fail(StageRead, "pinging image"); err != nil {
+		return imageresizer.ImageInfo{}, err
+	}
+	return f.info(""), nil
+}
+
+// info returns the attributes of an image in the given format.
+func (f *Fake) info(format string) imageresizer.ImageInfo {
+	if f.Info != nil {
+		return *f.Info
+	}
+	width, height := f.dimensions()
+	return imageresizer.ImageInfo{
+		Format:     format,
+		Width:      width,
+		Height:     height,
+		Frames:     1,
+		Colorspace: "sRGB",
+		Depth:      8,
+		Resolution: 72,
+	}
+}
+
+// Placeholder returns the configured placeholders.
+func (f *Fake) Placeholder(imageFilePath string) (imageresizer.Placeholder, error) {
+	f.record("Placeholder", imageFilePath)
+	if err := f.fail(StageRead, "reading image %s", imageFilePath); err != nil {
+		return imageresizer.Placeholder{}, err
+	}
+	if err := f.fail(StagePlaceholder, "computing placeholder"); err != nil {
+		return imageresizer.Placeholder{}, err
+	}
+	return f.PlaceholderResult, nil
+}
+
+// Colors returns the configured colors.
+func (f *Fake) Colors(imageFilePath string) (imageresizer.Colors, error) {
+	f.record("Colors", imageFilePath)
+	if err := f.fail(StageRead, "reading image %s", imageFilePath); err != nil {
+		return imageresizer.Colors{}, err
+	}
+	if err := f.fail(StageColors, "analyzing colors"); err != nil {
+		return imageresizer.Colors{}, err
+	}
+	return f.ColorsResult, nil
+}
+
+// PerceptualHash returns the configured hash of imageFilePath, or 0 if none is.
+func (f *Fake) PerceptualHash(imageFilePath string) (uint64, error) {
+	f.record("PerceptualHash", imageFilePath)
+	if err := f.fail(StageRead, "reading image %s", imageFilePath); err != nil {
+		return 0, err
+	}
+	if err := f.fail(StageHash, "computing perceptual hash"); err != nil {
+		return 0, err
+	}
+	return f.Hashes[imageFilePath], nil
+}
+
+// Compare returns the configured comparison.
+func (f *Fake) Compare(imageFilePath, referenceFilePath string) (imageresizer.Comparison, error) {
+	f.record("Compare", imageFilePath, referenceFilePath)
+	if err := f.fail(StageCompare, "comparing %s to %s", imageFilePath, referenceFilePath); err != nil {
+		return imageresizer.Comparison{}, err
+	}
+	return f.Comparison, nil
+}
+
+// Destroy records that the fake was destroyed, which Destroyed reports.
+func (f *Fake) Destroy() {
+	f.record("Destroy")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.destroyed = true
+}
+
+// dimensions returns the dimensions of the images written and inspected.
+func (f *Fake) dimensions() (int, int) {
+	width, height := f.Width, f.Height
+	if width == 0 {
+		width = defaultSize
+	}
+	if height == 0 {
+		height = defaultSize
+	}
+	return width, height
+}
+
+// write writes a placeholder image to filePath: a mid-gray picture of the fake's dimensions,
+// encoded as JPEG, GIF or, for any other extension, PNG.
+func (f *Fake) write(filePath string) error {
+	if err := f.fail(StageWrite, "writing image %s", filePath); err != nil {
+		return err
+	}
+	width, height := f.dimensions()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for n := range img.Pix {
+		img.Pix[n] = 128
+	}
+	var buf bytes.Buffer
+	var err error
+	switch imageresizer.FormatOf(filePath) {
+	case "JPEG":
+		err = jpeg.Encode(&buf, img, nil)
+	case "GIF":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "encoding image %s", filePath)
+	}
+	if err := os.WriteFile(filePath, buf.Bytes(), 0o644); err != nil {
+		return errors.Wrapf(err, "writing image %s", filePath)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizertest
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-image-resizer/imageresizer"
+)
+
+func TestFake_Resize(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join("some", "dir", "someImage.jpg")
+	testCases := []struct {
+		name           string
+		fake           *Fake
+		input          string
+		expectedPath   string
+		expectedFormat string
+		expectedWidth  int
+		expectedHeight int
+		expectedError  string
+	}{
+		{
+			name:           "default",
+			fake:           &Fake{},
+			input:          input,
+			expectedPath:   "someImage_resized.jpg",
+			expectedFormat: "jpeg",
+			expectedWidth:  16,
+			expectedHeight: 16,
+		},
+		{
+			name:           "output format and dimensions",
+			fake:           &Fake{OutputFormat: "GIF", Width: 30, Height: 20},
+			input:          "someImage.png",
+			expectedPath:   "someImage_resized.gif",
+			expectedFormat: "gif",
+			expectedWidth:  30,
+			expectedHeight: 20,
+		},
+		{
+			name:           "unknown format written as png",
+			fake:           &Fake{},
+			input:          "someImage.webp",
+			expectedPath:   "someImage_resized.webp",
+			expectedFormat: "png",
+			expectedWidth:  16,
+			expectedHeight: 16,
+		},
+		{
+			name:          "error when reading",
+			fake:          &Fake{Errors: map[Stage]error{StageRead: errors.New("read error")}},
+			input:         input,
+			expectedError: "reading image " + input + ": read error",
+		},
+		{
+			name:          "error when resizing",
+			fake:          &Fake{Errors: map[Stage]error{StageResize: errors.New("resize error")}},
+			input:         input,
+			expectedError: "resizing image: resize error",
+		},
+		{
+			name:          "error when writing",
+			fake:          &Fake{Errors: map[Stage]error{StageWrite: errors.New("write error")}},
+			input:         input,
+			expectedError: "writing image " + filepath.Join(dir, "someImage_resized.jpg") + ": write error",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fake.OutputDir = dir
+			output, err := tc.fake.Resize(tc.input)
+			require.Equal(t, []Call{{Method: "Resize", Args: []string{tc.input}}}, tc.fake.Calls())
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError, err.Error())
+			} else {
+				if tc.expectedError != "" {
+					t.Fatalf(`expected error "%s", got nil`, tc.expectedError)
+				}
+				require.Equal(t, filepath.Join(dir, tc.expectedPath), output)
+				content, err := os.ReadFile(output)
+				require.NoError(t, err)
+				config, format, err := image.DecodeConfig(bytes.NewReader(content))
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedFormat, format)
+				require.Equal(t, tc.expectedWidth, config.Width)
+				require.Equal(t, tc.expectedHeight, config.Height)
+			}
+		})
+	}
+}
+
+func TestFake_ResizeIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	fake := &Fake{OutputDir: dir}
+	var contents [][]byte
+	for _, input := range []string{"a/someImage.jpg", "b/someImage.jpg"} {
+		output, err := fake.Resize(input)
+		require.NoError(t, err)
+		content, err := os.ReadFile(output)
+		require.NoError(t, err)
+		contents = append(contents, content)
+	}
+	require.Equal(t, contents[0], contents[1])
+}
+
+func TestFake_ResizeWithResult(t *testing.T) {
+	dir := t.TempDir()
+	placeholder := imageresizer.Placeholder{BlurHash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"}
+	colors := imageresizer.Colors{Average: "#808080", Dominant: "#808080"}
+	fake := &Fake{OutputDir: dir, Quality: 75, PlaceholderResult: placeholder, ColorsResult: colors}
+	result, err := fake.ResizeWithResult("someImage.png")
+	require.NoError(t, err)
+	require.Equal(t, imageresizer.Result{
+		Path:        filepath.Join(dir, "someImage_resized.png"),
+		Quality:     75,
+		Placeholder: placeholder,
+		Colors:      colors,
+	}, result)
+	require.FileExists(t, result.Path)
+
+	fake.Errors = map[Stage]error{StagePlaceholder: errors.New("placeholder error")}
+	_, err = fake.ResizeWithResult("someImage.png")
+	require.EqualError(t, err, "computing placeholder: placeholder error")
+}
+
+func TestFake_ResizePages(t *testing.T) {
+	dir := t.TempDir()
+	fake := &Fake{OutputDir: dir, OutputFormat: "png", Pages: 3}
+	outputs, err := fake.ResizePages("someDocument.pdf")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "someDocument_resized_page1.png"),
+		filepath.Join(dir, "someDocument_resized_page2.png"),
+		filepath.Join(dir, "someDocument_resized_page3.png"),
+	}, outputs)
+	for _, output := range outputs {
+		require.FileExists(t, output)
+	}
+
+	fake.Errors = map[Stage]error{StageResize: errors.New("resize error")}
+	_, err = fake.ResizePages("someDocument.pdf")
+	require.EqualError(t, err, "resizing page 1: resize error")
+}
+
+func TestFake_Inspect(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fake          *Fake
+		expectedInfo  imageresizer.ImageInfo
+		expectedError string
+	}{
+		{
+			name: "derived",
+			fake: &Fake{Width: 40, Height: 30},
+			expectedInfo: imageresizer.ImageInfo{
+				Format:     "JPEG",
+				Width:      40,
+				Height:     30,
+				Frames:     1,
+				Colorspace: "sRGB",
+				Depth:      8,
+				Resolution: 72,
+			},
+		},
+		{
+			name:         "configured",
+			fake:         &Fake{Info: &imageresizer.ImageInfo{Format: "TIFF", Frames: 4}},
+			expectedInfo: imageresizer.ImageInfo{Format: "TIFF", Frames: 4},
+		},
+		{
+			name:          "error",
+			fake:          &Fake{Errors: map[Stage]error{StageRead: errors.New("ping error")}},
+			expectedError: "pinging image someImage.jpg: ping error",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := tc.fake.Inspect("someImage.jpg")
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf(`expected no error, got "%v"`, err)
+				}
+				require.Equal(t, tc.expectedError, err.Error())
+			} else {
+				if tc.expectedError != "" {
+					t.Fatalf(`expected error "%s", got nil`, tc.expectedError)
+				}
+				require.Equal(t, tc.expectedInfo, info)
+			}
+		})
+	}
+}
+
+func TestFake_Analysis(t *testing.T) {
+	errAnalysis := errors.New("analysis error")
+	fake := &Fake{
+		PlaceholderResult: imageresizer.Placeholder{ThumbHash: "1QcSHQRnh493V4dIh4eXh1h4kJUI"},
+		ColorsResult:      imageresizer.Colors{Average: "#102030"},
+		Hashes:            map[string]uint64{"a.jpg": 0xff},
+		Comparison:        imageresizer.Comparison{SSIM: 0.99},
+	}
+
+	placeholder, err := fake.Placeholder("a.jpg")
+	require.NoError(t, err)
+	require.Equal(t, fake.PlaceholderResult, placeholder)
+	colors, err := fake.Colors("a.jpg")
+	require.NoError(t, err)
+	require.Equal(t, fake.ColorsResult, colors)
+	hash, err := fake.PerceptualHash("a.jpg")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xff), hash)
+	hash, err = fake.PerceptualHash("b.jpg")
+	require.NoError(t, err)
+	require.Zero(t, hash)
+	comparison, err := fake.Compare("a.jpg", "b.jpg")
+	require.NoError(t, err)
+	require.Equal(t, fake.Comparison, comparison)
+
+	fake.Errors = map[Stage]error{
+		StageColors:  errAnalysis,
+		StageHash:    errAnalysis,
+		StageCompare: errAnalysis,
+	}
+	_, err = fake.Colors("a.jpg")
+	require.EqualError(t, err, "analyzing colors: analysis error")
+	_, err = fake.PerceptualHash("a.jpg")
+	require.EqualError(t, err, "computing perceptual hash: analysis error")
+	_, err = fake.Compare("a.jpg", "b.jpg")
+	require.EqualError(t, err, "comparing a.jpg to b.jpg: analysis error")
+	require.ErrorIs(t, err, errAnalysis)
+
+	require.Equal(t, []Call{
+		{Method: "Placeholder", Args: []string{"a.jpg"}},
+		{Method: "Colors", Args: []string{"a.jpg"}},
+		{Method: "PerceptualHash", Args: []string{"a.jpg"}},
+		{Method: "PerceptualHash", Args: []string{"b.jpg"}},
+		{Method: "Compare", Args: []string{"a.jpg", "b.jpg"}},
+		{Method: "Colors", Args: []string{"a.jpg"}},
+		{Method: "PerceptualHash", Args: []string{"a.jpg"}},
+		{Method: "Compare", Args: []string{"a.jpg", "b.jpg"}},
+	}, fake.Calls())
+}
+
+func TestFake_Concurrency(t *testing.T) {
+	fake := &Fake{OutputDir: t.TempDir()}
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fake.Inspect("someImage.png")
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	fake.Destroy()
+	require.Len(t, fake.Calls(), 11)
+	require.True(t, fake.Destroyed())
+}
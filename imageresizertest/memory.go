@@ -0,0 +1,15 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizertest
+
+import "github.com/tiagomelo/go-image-resizer/imageresizer"
+
+// NewInMemory creates an ImageResizer with the given options that decodes, processes and
+// encodes PNG, JPEG and GIF images in memory with Go's image packages instead of ImageMagick.
+// Its outputs are deterministic but differ from those of ImageMagick; see
+// imageresizer.WithMemoryBackend for what it supports.
+func NewInMemory(options ...imageresizer.Option) imageresizer.ImageResizer {
+	return imageresizer.New(append(options, imageresizer.WithMemoryBackend())...)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package imageresizertest
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/go-image-resizer/imageresizer"
+)
+
+func TestNewInMemory(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "someImage.png")
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.NRGBA{R: 30, G: 144, B: 255, A: 255})
+		}
+	}
+	file, err := os.Create(input)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, img))
+	require.NoError(t, file.Close())
+
+	outputDir := t.TempDir()
+	ir := NewInMemory(
+		imageresizer.WithDimensions(20, 10),
+		imageresizer.WithOutputDir(outputDir),
+		imageresizer.WithOutputFormat("jpg"),
+		imageresizer.WithColors(1),
+	)
+	defer ir.Destroy()
+
+	info, err := ir.(imageresizer.Inspector).Inspect(input)
+	require.NoError(t, err)
+	require.Equal(t, "PNG", info.Format)
+	require.Equal(t, 40, info.Width)
+	require.Equal(t, 20, info.Height)
+
+	result, err := ir.(imageresizer.ResultResizer).ResizeWithResult(input)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(outputDir, "someImage_resized.jpg"), result.Path)
+	require.Equal(t, "#1e90ff", result.Colors.Average)
+	output, err := os.Open(result.Path)
+	require.NoError(t, err)
+	defer output.Close()
+	config, format, err := image.DecodeConfig(output)
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", format)
+	require.Equal(t, 20, config.Width)
+	require.Equal(t, 10, config.Height)
+}